@@ -0,0 +1,110 @@
+package lexer
+
+// A pair of opening and closing delimiters, like "(" and ")" or "/*" and "*/".
+type Delimiters struct {
+	Open, Close string
+}
+
+// A BalanceProfile determines what LexInner.Balanced considers to be brackets,
+// and what it skips over without looking for brackets.
+// Where delimiters overlap, comments take precedence over strings, and strings
+// over brackets; within each, earlier delimiters take precedence over later ones.
+type BalanceProfile struct {
+	// Bracket pairs, which must be properly nested.
+	Brackets []Delimiters
+	// String delimiters. Brackets inside strings are ignored.
+	Strings []Delimiters
+	// The escape character in strings, such as `\`, which keeps the character
+	// following it from closing the string. Leave empty if strings have no escapes.
+	Escape string
+	// Comment delimiters. Brackets inside comments are ignored.
+	// A comment closed by "\n" may also end at the end of the input.
+	Comments []Delimiters
+}
+
+// An open bracket, string or comment, for LexInner.Balanced.
+type opener struct {
+	delim Delimiters
+	mark  Mark
+}
+
+// Accept an opening bracket and everything up to and including its matching
+// closing bracket, skipping over strings and comments.
+// If the input does not start with an opening bracket, ScanNone is returned.
+// If the brackets are not balanced, or a string or comment is not closed,
+// an error is emitted and ScanError is returned. An unmatched opening bracket,
+// string or comment is reported at its own position; a closing bracket that
+// does not match is reported at the closing bracket.
+func (l *LexInner) Balanced(p *BalanceProfile) Scan {
+	o, ok := l.acceptDelimiter(p.Brackets)
+	if !ok {
+		return ScanNone
+	}
+	stack := []opener{o}
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		if o, ok := l.acceptDelimiter(p.Comments); ok {
+			if !l.Find(o.delim.Close) {
+				if o.delim.Close != "\n" {
+					l.Unmark(o.mark)
+					return l.errorf("Unterminated comment")
+				}
+				for l.Next() != Eof {
+				}
+			}
+			l.String(o.delim.Close)
+			continue
+		}
+		if o, ok := l.acceptDelimiter(p.Strings); ok {
+			if scan := l.skipString(p, o); scan != ScanOK {
+				return scan
+			}
+			continue
+		}
+		if l.String(top.delim.Close) {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		if o, ok := l.acceptDelimiter(p.Brackets); ok {
+			stack = append(stack, o)
+			continue
+		}
+		for _, delim := range p.Brackets {
+			if delim.Close != "" && l.HasPrefix(delim.Close) {
+				return l.errorf("Unexpected %q, expected %q to match %q on line %d",
+					delim.Close, top.delim.Close, top.delim.Open, top.mark.Line())
+			}
+		}
+		if l.Next() == Eof {
+			l.Unmark(top.mark)
+			return l.errorf("Unmatched %q", top.delim.Open)
+		}
+	}
+	return ScanOK
+}
+
+// Accept the first of the opening delimiters that matches.
+func (l *LexInner) acceptDelimiter(delims []Delimiters) (opener, bool) {
+	mark := l.Mark()
+	for _, delim := range delims {
+		if delim.Open != "" && l.String(delim.Open) {
+			return opener{delim, mark}, true
+		}
+	}
+	return opener{}, false
+}
+
+// Skip the rest of a string, up to and including its closing delimiter.
+func (l *LexInner) skipString(p *BalanceProfile, o opener) Scan {
+	for !l.String(o.delim.Close) {
+		if p.Escape != "" && l.String(p.Escape) {
+			l.Next()
+			continue
+		}
+		if l.Next() == Eof {
+			l.Unmark(o.mark)
+			return l.errorf("Unterminated string")
+		}
+	}
+	return ScanOK
+}
@@ -0,0 +1,69 @@
+package lexer_test
+
+import (
+	"testing"
+
+	"github.com/PieterD/lexer"
+)
+
+var templateCode = &lexer.BalanceProfile{
+	Brackets: []lexer.Delimiters{{"${", "}"}, {"{", "}"}, {"(", ")"}, {"[", "]"}},
+	Strings:  []lexer.Delimiters{{`"`, `"`}, {"'", "'"}},
+	Escape:   `\`,
+	Comments: []lexer.Delimiters{{"/*", "*/"}, {"//", "\n"}},
+}
+
+func balancedState(l *lexer.LexInner) lexer.StateFn {
+	l.Run(func(r rune) bool { return r != '$' && r != '{' && r != '(' && r != lexer.Eof })
+	l.Ignore()
+	switch l.Balanced(templateCode) {
+	case lexer.ScanNone:
+		return l.Errorf("Not a bracket")
+	case lexer.ScanError:
+		return nil
+	}
+	l.Emit(TokenSymbol)
+	return nil
+}
+
+func TestBalanced(t *testing.T) {
+	tests := []struct {
+		input string
+		val   string
+	}{
+		{"${x} y}", "${x}"},
+		{"a ${ f(x[1], {y: 2}) } b", "${ f(x[1], {y: 2}) }"},
+		{`${ "}" + '\'}' + "\"}" }}`, `${ "}" + '\'}' + "\"}" }`},
+		{"${ /* } */ x // }\n }}", "${ /* } */ x // }\n }"},
+		{"(a\n(b)\nc)d", "(a\n(b)\nc)"},
+	}
+	for _, test := range tests {
+		token := lexer.New("test", test.input, balancedState).Iterate().Token()
+		if token.Typ != TokenSymbol || token.Val != test.val {
+			t.Fatalf("%q: expected %q, got %#v", test.input, test.val, token)
+		}
+	}
+}
+
+func TestBalancedErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		err    string
+		line   int
+		column int
+	}{
+		{"x", "Not a bracket", 1, 2},
+		{"x ${ (a) ", `Unmatched "${"`, 1, 3},
+		{"${ f(\n[1, 2}", `Unexpected "}", expected "]" to match "[" on line 2`, 2, 6},
+		{"${ x\n + \"}", "Unterminated string", 2, 4},
+		{"${ /* }", "Unterminated comment", 1, 4},
+		{"${ x // }", `Unmatched "${"`, 1, 1},
+		{"(a\n(b\nc)d", `Unmatched "("`, 1, 1},
+	}
+	for _, test := range tests {
+		token := lexer.New("test", test.input, balancedState).Iterate().Token()
+		if token.Typ != lexer.TokenError || token.Val != test.err || token.Line != test.line || token.Column != test.column {
+			t.Fatalf("%q: expected error %q at %d:%d, got %#v", test.input, test.err, test.line, test.column, token)
+		}
+	}
+}
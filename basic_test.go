@@ -88,16 +88,16 @@ foo = 500;
 barbaz="Hello world";
 `
 	tokens := []lexer.Token{
-		lexer.Token{TokenSymbol, "foo", "anonymous", 2},
-		lexer.Token{TokenEquals, "=", "anonymous", 2},
-		lexer.Token{TokenNumber, "500", "anonymous", 2},
-		lexer.Token{TokenSemi, ";", "anonymous", 2},
-		lexer.Token{TokenSymbol, "barbaz", "anonymous", 3},
-		lexer.Token{TokenEquals, "=", "anonymous", 3},
-		lexer.Token{TokenString, "\"Hello world\"", "anonymous", 3},
-		lexer.Token{TokenSemi, ";", "anonymous", 3},
-		lexer.Token{lexer.TokenEOF, "EOF", "anonymous", 3},
-		lexer.Token{lexer.TokenEmpty, "", "", 0},
+		lexer.Token{TokenSymbol, "foo", "anonymous", 2, 1, 1, 1, 0, 0},
+		lexer.Token{TokenEquals, "=", "anonymous", 2, 5, 5, 5, 0, 0},
+		lexer.Token{TokenNumber, "500", "anonymous", 2, 7, 7, 7, 0, 0},
+		lexer.Token{TokenSemi, ";", "anonymous", 2, 10, 10, 10, 0, 0},
+		lexer.Token{TokenSymbol, "barbaz", "anonymous", 3, 1, 1, 12, 0, 0},
+		lexer.Token{TokenEquals, "=", "anonymous", 3, 7, 7, 18, 0, 0},
+		lexer.Token{TokenString, "\"Hello world\"", "anonymous", 3, 8, 8, 19, 0, 0},
+		lexer.Token{TokenSemi, ";", "anonymous", 3, 21, 21, 32, 0, 0},
+		lexer.Token{lexer.TokenEOF, "EOF", "anonymous", 4, 1, 1, 34, 0, 0},
+		lexer.Token{lexer.TokenEmpty, "", "", 0, 0, 0, 0, 0, 0},
 	}
 	l := lexer.New("anonymous", text, symbolState)
 	it := l.Iterate()
@@ -105,7 +105,7 @@ barbaz="Hello world";
 		t.Fatalf("Second Iterate should return nil")
 	}
 	for i, expected := range tokens {
-		token := it.Token()
+		token := stripPos(t, l.FileSet(), it.Token())
 		if token != expected {
 			t.Fatalf("Token %d invalid: %#v expected %#v", i, token, expected)
 		}
@@ -117,13 +117,31 @@ barbaz="Hello world";
 		t.Fatalf("Second go should return nil")
 	}
 	for i, expected := range tokens {
-		token := <-tokenchan
+		token := stripPos(t, l.FileSet(), <-tokenchan)
 		if token != expected {
 			t.Fatalf("Token %d invalid: %#v expected %#v", i, token, expected)
 		}
 	}
 }
 
+// Check that the span of the token resolves to its position, and clear it.
+func stripPos(t *testing.T, fset *lexer.FileSet, token lexer.Token) lexer.Token {
+	if token.Typ != lexer.TokenEmpty {
+		pos := fset.Position(token.Pos)
+		expected := lexer.Position{Filename: token.File, Offset: token.Offset, Line: token.Line, Column: token.Column}
+		if pos != expected {
+			t.Fatalf("Token %#v resolved to %#v", token, pos)
+		}
+		end := fset.Position(token.End)
+		if token.Typ > 0 && end.Offset != token.Offset+len(token.Val) {
+			t.Fatalf("Token %#v ends at %#v", token, end)
+		}
+	}
+	token.Pos = lexer.NoPos
+	token.End = lexer.NoPos
+	return token
+}
+
 func TestLexTestBig(t *testing.T) {
 	lt := lextest.NewTester(t, symbolState, `
 hello="world";
@@ -133,11 +151,11 @@ num=500;
 	lt.Expect(TokenEquals, "=", 2)
 	lt.Expect(TokenString, "\"world\"", 2)
 	lt.Expect(TokenSemi, ";", 2)
-	lt.Expect(TokenSymbol, "num", 3)
+	lt.Expect(TokenSymbol, "num", 3).Column(1)
 	lt.Expect(TokenEquals, "=", 3)
-	lt.Expect(TokenNumber, "500", 3)
+	lt.Expect(TokenNumber, "500", 3).Column(5)
 	lt.Expect(TokenSemi, ";", 3)
-	lt.Expect(lexer.TokenEOF, "EOF", 3)
+	lt.Expect(lexer.TokenEOF, "EOF", 4)
 	lt.End()
 	lt.End()
 }
@@ -146,7 +164,7 @@ func TestLexTestSmall(t *testing.T) {
 	s := afterOperatorState
 	lextest.NewTester(t, s, `"hello"`).Expect(TokenString, `"hello"`, 1)
 	lextest.NewTester(t, s, `"he\"llo"`).Expect(TokenString, `"he\"llo"`, 1)
-	lextest.NewTester(t, s, `"he\!llo"`).Error(`Expected a known escape character (\ or "), instead of: !`, 1)
+	lextest.NewTester(t, s, `"he\!llo"`).Error(`Expected a known escape character (\ or "), instead of: !`, 1).Column(6)
 	lextest.NewTester(t, s, `"hello`).Error(`EOF in the middle of a string!`, 1)
 }
 
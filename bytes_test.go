@@ -0,0 +1,68 @@
+package lexer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PieterD/lexer"
+)
+
+func TestNewBytes(t *testing.T) {
+	input := []byte("\nfoo = 500;\nbarbaz=\"Hello world\";\n")
+	expected := lexer.New("anonymous", string(input), symbolState).Iterate()
+	it := lexer.NewBytes("anonymous", input, symbolState).Iterate()
+	var tokens []lexer.Token
+	for {
+		exp := expected.Token()
+		token := it.Token()
+		if token != exp {
+			t.Fatalf("Token %d invalid: %#v expected %#v", len(tokens), token, exp)
+		}
+		tokens = append(tokens, token)
+		if token.Typ == lexer.TokenEOF {
+			break
+		}
+	}
+	for i := range input {
+		input[i] = 'X'
+	}
+	if tokens[0].Val != "foo" {
+		t.Fatalf("Token value changed along with the input: %#v", tokens[0])
+	}
+}
+
+func TestGetBytes(t *testing.T) {
+	input := []byte("hello world")
+	state := func(l *lexer.LexInner) lexer.StateFn {
+		l.ExceptRun(" ")
+		b := l.GetBytes()
+		if string(b) != "hello" || &b[0] != &input[0] || cap(b) != len(b) {
+			return l.Errorf("GetBytes returned %q", b)
+		}
+		l.Emit(1)
+		return nil
+	}
+	it := lexer.NewBytes("test", input, state).Iterate()
+	if token := it.Token(); token.Val != "hello" {
+		t.Fatalf("Unexpected token %#v", token)
+	}
+}
+
+func TestBytesFileSetColumns(t *testing.T) {
+	input := "é = 1;"
+	for _, ln := range []*lexer.Lexer{
+		lexer.NewBytes("test", []byte(input), symbolState),
+		lexer.NewReader("test", strings.NewReader(input), symbolState),
+	} {
+		it := ln.Iterate()
+		it.Token()
+		token := it.Token()
+		if token.Val != "=" || token.Column != 3 {
+			t.Fatalf("Unexpected token %#v", token)
+		}
+		// The input is not kept, so the FileSet counts bytes.
+		if pos := ln.FileSet().Position(token.Pos); pos.Line != 1 || pos.Column != 4 {
+			t.Fatalf("Expected byte column 4, got %s", pos)
+		}
+	}
+}
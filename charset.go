@@ -0,0 +1,147 @@
+package lexer
+
+import (
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A CharSet is a precompiled set of characters, for use with AcceptSet and
+// AcceptSetRun. Membership of ASCII characters is a single bit test.
+// The zero value is the empty set. Eof is never part of a CharSet.
+type CharSet struct {
+	ascii [2]uint64
+	other func(rune) bool
+}
+
+// Create a CharSet containing the characters in the given string.
+func Chars(chars string) CharSet {
+	var cs CharSet
+	var other []rune
+	for _, char := range chars {
+		if char < utf8.RuneSelf {
+			cs.ascii[char/64] |= 1 << uint(char%64)
+		} else {
+			other = append(other, char)
+		}
+	}
+	if len(other) > 0 {
+		sort.Slice(other, func(i, j int) bool {
+			return other[i] < other[j]
+		})
+		cs.other = func(char rune) bool {
+			i := sort.Search(len(other), func(i int) bool {
+				return other[i] >= char
+			})
+			return i < len(other) && other[i] == char
+		}
+	}
+	return cs
+}
+
+// Create a CharSet containing the characters from lo up to and including hi.
+func Range(lo, hi rune) CharSet {
+	var cs CharSet
+	for char := lo; char <= hi && char < utf8.RuneSelf; char++ {
+		if char >= 0 {
+			cs.ascii[char/64] |= 1 << uint(char%64)
+		}
+	}
+	if hi >= utf8.RuneSelf {
+		cs.other = func(char rune) bool {
+			return char >= lo && char <= hi
+		}
+	}
+	return cs
+}
+
+// Create a CharSet containing the characters in any of the given tables,
+// such as unicode.Letter.
+func Table(tables ...*unicode.RangeTable) CharSet {
+	return charFunc(func(char rune) bool {
+		return unicode.In(char, tables...)
+	})
+}
+
+// Create a CharSet containing the characters for which f returns true.
+func charFunc(f func(rune) bool) CharSet {
+	var cs CharSet
+	for char := rune(0); char < utf8.RuneSelf; char++ {
+		if f(char) {
+			cs.ascii[char/64] |= 1 << uint(char%64)
+		}
+	}
+	cs.other = f
+	return cs
+}
+
+// Return a CharSet containing the characters in any of the given sets.
+func (cs CharSet) Union(sets ...CharSet) CharSet {
+	others := make([]func(rune) bool, 0, len(sets)+1)
+	if cs.other != nil {
+		others = append(others, cs.other)
+	}
+	for _, set := range sets {
+		cs.ascii[0] |= set.ascii[0]
+		cs.ascii[1] |= set.ascii[1]
+		if set.other != nil {
+			others = append(others, set.other)
+		}
+	}
+	switch len(others) {
+	case 0:
+		cs.other = nil
+	case 1:
+		cs.other = others[0]
+	default:
+		cs.other = func(char rune) bool {
+			for _, other := range others {
+				if other(char) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return cs
+}
+
+// Return a CharSet containing all characters not in this one.
+// Like Except, it does not contain Err.
+func (cs CharSet) Negate() CharSet {
+	other := cs.other
+	cs.ascii[0] = ^cs.ascii[0]
+	cs.ascii[1] = ^cs.ascii[1]
+	cs.other = func(char rune) bool {
+		return char != Err && (other == nil || !other(char))
+	}
+	return cs
+}
+
+// Return true if the character is in the CharSet.
+func (cs CharSet) Contains(char rune) bool {
+	if char < 0 {
+		return false
+	}
+	if char < utf8.RuneSelf {
+		return cs.ascii[char/64]&(1<<uint(char%64)) != 0
+	}
+	return cs.other != nil && cs.other(char)
+}
+
+// Read one character, but only if it is in the CharSet.
+func (l *LexInner) AcceptSet(cs CharSet) bool {
+	if cs.Contains(l.Next()) {
+		return true
+	}
+	l.Back()
+	return false
+}
+
+// Read as many characters as possible, but only characters in the CharSet.
+func (l *LexInner) AcceptSetRun(cs CharSet) (acceptnum int) {
+	for l.AcceptSet(cs) {
+		acceptnum++
+	}
+	return
+}
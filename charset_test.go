@@ -0,0 +1,62 @@
+package lexer
+
+import (
+	"testing"
+	"unicode"
+)
+
+func TestCharSet(t *testing.T) {
+	digits := Range('0', '9')
+	hex := digits.Union(Range('a', 'f'), Chars("ABCDEF"))
+	greek := Table(unicode.Greek)
+	word := Table(unicode.Letter).Union(digits, Chars("_"))
+	tests := []struct {
+		cs      CharSet
+		in, out string
+	}{
+		{digits, "0129", "/:a٣"},
+		{hex, "09afAF", "gG-ä"},
+		{greek, "αΩ", "aä"},
+		{word, "aZ_9äα", " -+\t"},
+		{Chars("äb"), "äb", "aöx"},
+		{word.Negate(), " -+\t", "aZ_9äα"},
+		{CharSet{}, "", "a\x00ä"},
+	}
+	for i, test := range tests {
+		for _, char := range test.in {
+			if !test.cs.Contains(char) {
+				t.Fatalf("Set %d: expected %q to be contained", i, char)
+			}
+		}
+		for _, char := range test.out {
+			if test.cs.Contains(char) {
+				t.Fatalf("Set %d: expected %q not to be contained", i, char)
+			}
+		}
+		if test.cs.Contains(Eof) {
+			t.Fatalf("Set %d: Eof is contained", i)
+		}
+	}
+	if word.Negate().Contains(Err) {
+		t.Fatalf("Negated set contains Err")
+	}
+}
+
+func TestAcceptSet(t *testing.T) {
+	l := New("test", "0x1fA9g\nrest", nil).lexer
+	hex := Range('0', '9').Union(Range('a', 'f'), Range('A', 'F'))
+	if !l.AcceptSet(Chars("0")) || l.AcceptSet(hex) {
+		t.Fatalf("AcceptSet failed")
+	}
+	l.Next()
+	if n := l.AcceptSetRun(hex); n != 4 || l.Get() != "0x1fA9" {
+		t.Fatalf("AcceptSetRun accepted %d, got %q", n, l.Get())
+	}
+	if n := l.AcceptSetRun(Chars("\n").Negate()); n != 1 || l.Peek() != '\n' {
+		t.Fatalf("Negated AcceptSetRun accepted %d", n)
+	}
+	l.AcceptSetRun(Chars("").Negate())
+	if !l.Eof() || l.mark.line != 2 {
+		t.Fatalf("Expected Eof on line 2")
+	}
+}
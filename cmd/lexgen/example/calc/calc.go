@@ -0,0 +1,243 @@
+// Code generated by lexgen from calc.lex; DO NOT EDIT.
+
+package calc
+
+import (
+	"strconv"
+
+	"github.com/PieterD/lexer"
+)
+
+// TokenType is the type of the tokens emitted by the lexer.
+// Convert it to lexer.TokenType to compare it with the Typ of a Token.
+type TokenType lexer.TokenType
+
+const (
+	Number TokenType = 1 + iota
+	Let
+	Ident
+	Assign
+	Plus
+	Minus
+	Times
+	Divide
+	LParen
+	RParen
+	Quote
+	EndExpr
+	Text
+	BeginExpr
+)
+
+// Return the name of the token type.
+func (typ TokenType) String() string {
+	switch lexer.TokenType(typ) {
+	case lexer.TokenEmpty:
+		return "Empty"
+	case lexer.TokenError:
+		return "Error"
+	case lexer.TokenWarning:
+		return "Warning"
+	case lexer.TokenEOF:
+		return "EOF"
+	case lexer.TokenType(Number):
+		return "Number"
+	case lexer.TokenType(Let):
+		return "Let"
+	case lexer.TokenType(Ident):
+		return "Ident"
+	case lexer.TokenType(Assign):
+		return "Assign"
+	case lexer.TokenType(Plus):
+		return "Plus"
+	case lexer.TokenType(Minus):
+		return "Minus"
+	case lexer.TokenType(Times):
+		return "Times"
+	case lexer.TokenType(Divide):
+		return "Divide"
+	case lexer.TokenType(LParen):
+		return "LParen"
+	case lexer.TokenType(RParen):
+		return "RParen"
+	case lexer.TokenType(Quote):
+		return "Quote"
+	case lexer.TokenType(EndExpr):
+		return "EndExpr"
+	case lexer.TokenType(Text):
+		return "Text"
+	case lexer.TokenType(BeginExpr):
+		return "BeginExpr"
+	}
+	return "TokenType(" + strconv.Itoa(int(typ)) + ")"
+}
+
+var (
+	reMain0   = lexer.MustCompileAnchored(`[ \t\r\n]+`, false)
+	reMain1   = lexer.MustCompileAnchored(`#.*`, false)
+	reMain2   = lexer.MustCompileAnchored(`[0-9]+(\.[0-9]+)?`, false)
+	reMain4   = lexer.MustCompileAnchored(`[A-Za-z_][A-Za-z0-9_]*`, false)
+	reString0 = lexer.MustCompileAnchored(`([^"\\$]|\\.|\$[^{"])+`, false)
+)
+
+// Lex the main mode.
+// Lexing starts in this mode.
+// At every position, the rule with the longest match is used; if several
+// are equally long, the first of them is used.
+func LexMain(l *lexer.LexInner) lexer.StateFn {
+	for {
+		if l.Eof() {
+			return l.EmitEof()
+		}
+		start := l.Mark()
+		best, length, end := -1, 0, start
+		if l.Regexp(reMain0) && l.Len() > length {
+			best, length, end = 0, l.Len(), l.Mark()
+		}
+		l.Unmark(start)
+		if l.Regexp(reMain1) && l.Len() > length {
+			best, length, end = 1, l.Len(), l.Mark()
+		}
+		l.Unmark(start)
+		if l.Regexp(reMain2) && l.Len() > length {
+			best, length, end = 2, l.Len(), l.Mark()
+		}
+		l.Unmark(start)
+		if l.String(`let`) && l.Len() > length {
+			best, length, end = 3, l.Len(), l.Mark()
+		}
+		l.Unmark(start)
+		if l.Regexp(reMain4) && l.Len() > length {
+			best, length, end = 4, l.Len(), l.Mark()
+		}
+		l.Unmark(start)
+		if l.String(`=`) && l.Len() > length {
+			best, length, end = 5, l.Len(), l.Mark()
+		}
+		l.Unmark(start)
+		if l.String(`+`) && l.Len() > length {
+			best, length, end = 6, l.Len(), l.Mark()
+		}
+		l.Unmark(start)
+		if l.String(`-`) && l.Len() > length {
+			best, length, end = 7, l.Len(), l.Mark()
+		}
+		l.Unmark(start)
+		if l.String(`*`) && l.Len() > length {
+			best, length, end = 8, l.Len(), l.Mark()
+		}
+		l.Unmark(start)
+		if l.String(`/`) && l.Len() > length {
+			best, length, end = 9, l.Len(), l.Mark()
+		}
+		l.Unmark(start)
+		if l.String(`(`) && l.Len() > length {
+			best, length, end = 10, l.Len(), l.Mark()
+		}
+		l.Unmark(start)
+		if l.String(`)`) && l.Len() > length {
+			best, length, end = 11, l.Len(), l.Mark()
+		}
+		l.Unmark(start)
+		if l.String(`"`) && l.Len() > length {
+			best, length, end = 12, l.Len(), l.Mark()
+		}
+		l.Unmark(start)
+		if l.String(`}`) && l.Len() > length {
+			best, length, end = 13, l.Len(), l.Mark()
+		}
+		l.Unmark(start)
+		if best < 0 {
+			return l.Errorf("Unexpected character %q", l.Peek())
+		}
+		l.Unmark(end)
+		switch best {
+		case 0:
+			l.Ignore()
+		case 1:
+			l.Ignore()
+		case 2:
+			l.Emit(lexer.TokenType(Number))
+			return LexMain
+		case 3:
+			l.Emit(lexer.TokenType(Let))
+			return LexMain
+		case 4:
+			l.Emit(lexer.TokenType(Ident))
+			return LexMain
+		case 5:
+			l.Emit(lexer.TokenType(Assign))
+			return LexMain
+		case 6:
+			l.Emit(lexer.TokenType(Plus))
+			return LexMain
+		case 7:
+			l.Emit(lexer.TokenType(Minus))
+			return LexMain
+		case 8:
+			l.Emit(lexer.TokenType(Times))
+			return LexMain
+		case 9:
+			l.Emit(lexer.TokenType(Divide))
+			return LexMain
+		case 10:
+			l.Emit(lexer.TokenType(LParen))
+			return LexMain
+		case 11:
+			l.Emit(lexer.TokenType(RParen))
+			return LexMain
+		case 12:
+			l.Emit(lexer.TokenType(Quote))
+			if !l.PushState(LexMain) {
+				return nil
+			}
+			return LexString
+		case 13:
+			l.Emit(lexer.TokenType(EndExpr))
+			return l.PopState()
+		}
+	}
+}
+
+// Lex the string mode.
+// At every position, the rule with the longest match is used; if several
+// are equally long, the first of them is used.
+func LexString(l *lexer.LexInner) lexer.StateFn {
+	for {
+		if l.Eof() {
+			return l.EmitEof()
+		}
+		start := l.Mark()
+		best, length, end := -1, 0, start
+		if l.Regexp(reString0) && l.Len() > length {
+			best, length, end = 0, l.Len(), l.Mark()
+		}
+		l.Unmark(start)
+		if l.String(`${`) && l.Len() > length {
+			best, length, end = 1, l.Len(), l.Mark()
+		}
+		l.Unmark(start)
+		if l.String(`"`) && l.Len() > length {
+			best, length, end = 2, l.Len(), l.Mark()
+		}
+		l.Unmark(start)
+		if best < 0 {
+			return l.Errorf("Unexpected character %q", l.Peek())
+		}
+		l.Unmark(end)
+		switch best {
+		case 0:
+			l.Emit(lexer.TokenType(Text))
+			return LexString
+		case 1:
+			l.Emit(lexer.TokenType(BeginExpr))
+			if !l.PushState(LexString) {
+				return nil
+			}
+			return LexMain
+		case 2:
+			l.Emit(lexer.TokenType(Quote))
+			return l.PopState()
+		}
+	}
+}
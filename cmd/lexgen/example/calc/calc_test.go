@@ -0,0 +1,84 @@
+package calc_test
+
+import (
+	"testing"
+
+	"github.com/PieterD/lexer"
+	"github.com/PieterD/lexer/cmd/lexgen/example/calc"
+	"github.com/PieterD/lexer/lextest"
+)
+
+func typ(t calc.TokenType) lexer.TokenType {
+	return lexer.TokenType(t)
+}
+
+func TestCalc(t *testing.T) {
+	lextest.NewTester(t, calc.LexMain, "let letter = 2.5 * (x - 1) # comment\n/ y").
+		Expect(typ(calc.Let), "let", 1).Column(1).
+		Expect(typ(calc.Ident), "letter", 1).Column(5).
+		Expect(typ(calc.Assign), "=", 1).
+		Expect(typ(calc.Number), "2.5", 1).
+		Expect(typ(calc.Times), "*", 1).
+		Expect(typ(calc.LParen), "(", 1).
+		Expect(typ(calc.Ident), "x", 1).
+		Expect(typ(calc.Minus), "-", 1).
+		Expect(typ(calc.Number), "1", 1).
+		Expect(typ(calc.RParen), ")", 1).
+		Expect(typ(calc.Divide), "/", 2).Column(1).
+		Expect(typ(calc.Ident), "y", 2).
+		Expect(lexer.TokenEOF, "EOF", 2).
+		End()
+}
+
+func TestCalcInterpolation(t *testing.T) {
+	lextest.NewTester(t, calc.LexMain, `x = "a $b ${ "c${d}" + 1 }\""`).
+		Expect(typ(calc.Ident), "x", 1).
+		Expect(typ(calc.Assign), "=", 1).
+		Expect(typ(calc.Quote), `"`, 1).
+		Expect(typ(calc.Text), "a $b ", 1).
+		Expect(typ(calc.BeginExpr), "${", 1).
+		Expect(typ(calc.Quote), `"`, 1).
+		Expect(typ(calc.Text), "c", 1).
+		Expect(typ(calc.BeginExpr), "${", 1).
+		Expect(typ(calc.Ident), "d", 1).
+		Expect(typ(calc.EndExpr), "}", 1).
+		Expect(typ(calc.Quote), `"`, 1).
+		Expect(typ(calc.Plus), "+", 1).
+		Expect(typ(calc.Number), "1", 1).
+		Expect(typ(calc.EndExpr), "}", 1).
+		Expect(typ(calc.Text), `\"`, 1).
+		Expect(typ(calc.Quote), `"`, 1).
+		Expect(lexer.TokenEOF, "EOF", 1).
+		End()
+}
+
+func TestCalcErrors(t *testing.T) {
+	lextest.NewTester(t, calc.LexMain, "1 % 2").
+		Expect(typ(calc.Number), "1", 1).
+		Error("Unexpected character '%'", 1).Column(3).
+		End()
+	lextest.NewTester(t, calc.LexMain, "1 }").
+		Expect(typ(calc.Number), "1", 1).
+		Expect(typ(calc.EndExpr), "}", 1).
+		Error("No state to return to", 1).
+		End()
+	lextest.NewTester(t, calc.LexMain, `"abc`).
+		Expect(typ(calc.Quote), `"`, 1).
+		Expect(typ(calc.Text), "abc", 1).
+		Error("Unexpected end of input with 1 unpopped states", 1).
+		End()
+}
+
+func TestTokenTypeString(t *testing.T) {
+	for typ, name := range map[calc.TokenType]string{
+		calc.Number:                      "Number",
+		calc.BeginExpr:                   "BeginExpr",
+		calc.TokenType(lexer.TokenEOF):   "EOF",
+		calc.TokenType(lexer.TokenEmpty): "Empty",
+		calc.TokenType(100):              "TokenType(100)",
+	} {
+		if typ.String() != name {
+			t.Fatalf("Expected %s, got %s", name, typ)
+		}
+	}
+}
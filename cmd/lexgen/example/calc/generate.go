@@ -0,0 +1,4 @@
+// Package calc is an example of a lexer generated by lexgen, from calc.lex.
+package calc
+
+//go:generate go run github.com/PieterD/lexer/cmd/lexgen -o calc.go calc.lex
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Generate the Go source of the lexer for the specification.
+// The source is named after the file it was generated from.
+func generate(s *spec, file string) ([]byte, error) {
+	var buf bytes.Buffer
+	p := func(format string, args ...interface{}) {
+		fmt.Fprintf(&buf, format+"\n", args...)
+	}
+	p("// Code generated by lexgen from %s; DO NOT EDIT.", file)
+	p("")
+	p("package %s", s.pkg)
+	p("")
+	p("import (")
+	p("%q", "strconv")
+	p("")
+	p("%q", "github.com/PieterD/lexer")
+	p(")")
+	p("")
+	p("// TokenType is the type of the tokens emitted by the lexer.")
+	p("// Convert it to lexer.TokenType to compare it with the Typ of a Token.")
+	p("type TokenType lexer.TokenType")
+	p("")
+	if len(s.tokens) > 0 {
+		p("const (")
+		for i, name := range s.tokens {
+			if i == 0 {
+				p("%s TokenType = 1 + iota", name)
+			} else {
+				p("%s", name)
+			}
+		}
+		p(")")
+		p("")
+	}
+	p("// Return the name of the token type.")
+	p("func (typ TokenType) String() string {")
+	p("switch lexer.TokenType(typ) {")
+	for _, builtin := range []string{"Empty", "Error", "Warning", "EOF"} {
+		p("case lexer.Token%s:", builtin)
+		p("return %q", builtin)
+	}
+	for _, name := range s.tokens {
+		p("case lexer.TokenType(%s):", name)
+		p("return %q", name)
+	}
+	p("}")
+	p("return %q + strconv.Itoa(int(typ)) + %q", "TokenType(", ")")
+	p("}")
+	if s.hasRegexp() {
+		p("")
+		p("var (")
+		for _, m := range s.modes {
+			for i, ru := range m.rules {
+				if ru.regexp != "" {
+					p("%s = lexer.MustCompileAnchored(%s, false)", regexpName(m, i), quote(ru.regexp))
+				}
+			}
+		}
+		p(")")
+	}
+	for _, m := range s.modes {
+		p("")
+		s.generateMode(p, m)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("Formatting generated code: %v", err)
+	}
+	return src, nil
+}
+
+// Generate the StateFn for a mode.
+func (s *spec) generateMode(p func(string, ...interface{}), m *mode) {
+	p("// Lex the %s mode.", m.name)
+	if m == s.modes[0] {
+		p("// Lexing starts in this mode.")
+	}
+	p("// At every position, the rule with the longest match is used; if several")
+	p("// are equally long, the first of them is used.")
+	p("func %s(l *lexer.LexInner) lexer.StateFn {", stateName(m))
+	p("for {")
+	p("if l.Eof() {")
+	p("return l.EmitEof()")
+	p("}")
+	p("start := l.Mark()")
+	p("best, length, end := -1, 0, start")
+	for i, ru := range m.rules {
+		if ru.regexp != "" {
+			p("if l.Regexp(%s) && l.Len() > length {", regexpName(m, i))
+		} else {
+			p("if l.String(%s) && l.Len() > length {", quote(ru.literal))
+		}
+		p("best, length, end = %d, l.Len(), l.Mark()", i)
+		p("}")
+		p("l.Unmark(start)")
+	}
+	p("if best < 0 {")
+	p("return l.Errorf(\"Unexpected character %%q\", l.Peek())")
+	p("}")
+	p("l.Unmark(end)")
+	p("switch best {")
+	for i, ru := range m.rules {
+		p("case %d:", i)
+		if ru.token == "" {
+			p("l.Ignore()")
+		} else {
+			p("l.Emit(lexer.TokenType(%s))", ru.token)
+		}
+		switch {
+		case ru.push != "":
+			p("if !l.PushState(%s) {", stateName(m))
+			p("return nil")
+			p("}")
+			p("return %s", stateName(s.mode(ru.push)))
+		case ru.pop:
+			p("return l.PopState()")
+		case ru.token != "":
+			p("return %s", stateName(m))
+		}
+	}
+	p("}")
+	p("}")
+	p("}")
+}
+
+// Return true if any rule uses a regular expression.
+func (s *spec) hasRegexp() bool {
+	for _, m := range s.modes {
+		for _, ru := range m.rules {
+			if ru.regexp != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Return str as a Go string literal, preferring a raw one.
+func quote(str string) string {
+	if strconv.CanBackquote(str) {
+		return "`" + str + "`"
+	}
+	return strconv.Quote(str)
+}
+
+// Return the name of the StateFn for a mode.
+func stateName(m *mode) string {
+	return "Lex" + exported(m.name)
+}
+
+// Return the name of the variable holding the regular expression of a rule.
+func regexpName(m *mode, i int) string {
+	return fmt.Sprintf("re%s%d", exported(m.name), i)
+}
+
+// Return the name with its first letter in upper case.
+func exported(name string) string {
+	char, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(char)) + name[size:]
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the generated example")
+
+// The example is generated from its specification, and must be up to date.
+func TestGolden(t *testing.T) {
+	src, err := generateFile("example/calc/calc.lex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *update {
+		if err := os.WriteFile("example/calc/calc.go", src, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	golden, err := os.ReadFile("example/calc/calc.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, golden) {
+		t.Fatalf("example/calc/calc.go is out of date; run go generate or go test -update")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		spec string
+		err  string
+	}{
+		{"mode main\nA \"a\"", "test.lex:1: Missing package"},
+		{"package p", "test.lex:1: No modes"},
+		{"package p\nA \"a\"", "test.lex:2: Rule outside of a mode"},
+		{"package p\nmode m\nmode m", `test.lex:3: Duplicate mode "m"`},
+		{"package p\nmode m\n1A \"a\"", `test.lex:3: Invalid token name "1A"`},
+		{"package p\nmode m\nA a", `test.lex:3: Expected a "literal" or /regexp/`},
+		{"package p\nmode m\nA \"\"", "test.lex:3: Empty literal"},
+		{"package p\nmode m\nA \"a", `test.lex:3: Invalid literal "a`},
+		{"package p\nmode m\nA /a", "test.lex:3: Unterminated regular expression /a"},
+		{"package p\nmode m\nA /(/", "test.lex:3: Invalid regular expression: error parsing regexp: missing closing ): `(`"},
+		{"package p\nmode m\nA \"a\" => x", `test.lex:3: Invalid action "=> x"`},
+		{"package p\nmode m\nA \"a\" <- x", `test.lex:3: Invalid action "<- x"`},
+		{"package p\nmode m\n\nA \"a\" -> n", `test.lex:4: Unknown mode "n"`},
+		{"package p\nmode m\nA \"a\"\nmode n\n# none\n", `test.lex:4: Mode "n" has no rules`},
+		{"package p\nmode a\nA \"a\"\nmode A\nB \"b\"", `test.lex:4: Mode "A" generates "LexA", which is already used by mode "a"`},
+		{"package p\nmode main\nLexMain \"a\"", `test.lex:3: Token "LexMain" is already used by mode "main"`},
+		{"package p\nmode m\nTokenType \"a\"", `test.lex:3: Token "TokenType" is already used by the generated code`},
+		{"package p\nmode m\nend \"a\"", `test.lex:3: Token "end" is already used by the generated code`},
+		{"package p\nmode main\nreMain0 /a/", `test.lex:3: Regular expression generates "reMain0", which is already used by token "reMain0"`},
+		{"package p\nmode main\nA /a/\nmode LexMain\nB \"b\"\nreMain0 \"c\"", `test.lex:6: Token "reMain0" is already used by a regular expression`},
+	}
+	for _, test := range tests {
+		_, err := parse("test.lex", strings.NewReader(test.spec))
+		if err == nil || err.Error() != test.err {
+			t.Fatalf("%q: expected error %q, got %v", test.spec, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Lexgen generates a lexer from a specification.
+//
+// Usage:
+//
+//	lexgen [-o output.go] spec.lex
+//
+// The specification consists of lines of the following forms, along with
+// blank lines and comments starting with '#':
+//
+//	package NAME
+//	mode NAME
+//	TOKEN "literal" [-> MODE | <-]
+//	TOKEN /regexp/ [-> MODE | <-]
+//
+// TOKEN is the name of a token type, or skip to ignore the match.
+// Every mode needs at least one rule. With -> MODE, the rule switches to
+// MODE, which can return to the current mode with <-. The first mode is
+// the one lexing starts in.
+//
+// The generated code contains a TokenType for every token in the
+// specification, and a StateFn named Lex followed by the capitalized name
+// for every mode, which use LexInner to lex their rules. Specifications
+// whose names would collide in the generated code are rejected. It is meant to be run by go generate, with a line like
+//
+//	//go:generate go run github.com/PieterD/lexer/cmd/lexgen -o lexer.go lexer.lex
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("lexgen: ")
+	output := flag.String("o", "", "write the generated code to this file instead of standard output")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: lexgen [-o output.go] spec.lex\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	src, err := generateFile(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(src)
+	} else {
+		err = os.WriteFile(*output, src, 0666)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Read a specification and generate its lexer.
+func generateFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	s, err := parse(path, f)
+	if err != nil {
+		return nil, err
+	}
+	return generate(s, filepath.Base(path))
+}
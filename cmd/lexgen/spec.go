@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"go/token"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A lexer specification.
+type spec struct {
+	pkg    string
+	tokens []string
+	modes  []*mode
+}
+
+// A mode is a set of rules, which becomes a single StateFn.
+type mode struct {
+	name  string
+	rules []rule
+	line  int
+}
+
+// A rule matches a literal or regular expression, and emits a token
+// or skips the match.
+type rule struct {
+	token   string
+	literal string
+	regexp  string
+	push    string
+	pop     bool
+	line    int
+}
+
+// An error in a specification, with its position.
+type specError struct {
+	file string
+	line int
+	msg  string
+}
+
+func (err *specError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", err.file, err.line, err.msg)
+}
+
+// Parse a specification in the format described in the command documentation.
+func parse(file string, r io.Reader) (*spec, error) {
+	s := new(spec)
+	seen := make(map[string]bool)
+	var cur *mode
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		errorf := func(format string, args ...interface{}) error {
+			return &specError{file, n, fmt.Sprintf(format, args...)}
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		word, rest := cut(line)
+		switch word {
+		case "package", "mode":
+			if !token.IsIdentifier(rest) {
+				return nil, errorf("Invalid %s name %q", word, rest)
+			}
+			if word == "package" {
+				s.pkg = rest
+				continue
+			}
+			if s.mode(rest) != nil {
+				return nil, errorf("Duplicate mode %q", rest)
+			}
+			cur = &mode{name: rest, line: n}
+			s.modes = append(s.modes, cur)
+			continue
+		}
+		if cur == nil {
+			return nil, errorf("Rule outside of a mode")
+		}
+		if !token.IsIdentifier(word) {
+			return nil, errorf("Invalid token name %q", word)
+		}
+		ru := rule{line: n}
+		if word != "skip" {
+			ru.token = word
+			if !seen[word] {
+				seen[word] = true
+				s.tokens = append(s.tokens, word)
+			}
+		}
+		var err error
+		if rest, err = ru.parsePattern(rest); err != nil {
+			return nil, errorf("%v", err)
+		}
+		switch action, target := cut(rest); action {
+		case "":
+		case "->":
+			ru.push = target
+		case "<-":
+			ru.pop = target == ""
+		}
+		if rest != "" && ru.push == "" && !ru.pop {
+			return nil, errorf("Invalid action %q", rest)
+		}
+		cur.rules = append(cur.rules, ru)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return s, s.check(file)
+}
+
+// Parse the pattern at the start of str, and return the rest of it.
+func (ru *rule) parsePattern(str string) (string, error) {
+	switch {
+	case strings.HasPrefix(str, `"`):
+		quoted, err := strconv.QuotedPrefix(str)
+		if err != nil {
+			return "", fmt.Errorf("Invalid literal %s", str)
+		}
+		ru.literal, _ = strconv.Unquote(quoted)
+		if ru.literal == "" {
+			return "", fmt.Errorf("Empty literal")
+		}
+		return strings.TrimSpace(str[len(quoted):]), nil
+	case strings.HasPrefix(str, "/"):
+		end := strings.LastIndex(str, "/")
+		if end == 0 {
+			return "", fmt.Errorf("Unterminated regular expression %s", str)
+		}
+		ru.regexp = str[1:end]
+		if _, err := regexp.Compile(ru.regexp); err != nil {
+			return "", fmt.Errorf("Invalid regular expression: %v", err)
+		}
+		return strings.TrimSpace(str[end+1:]), nil
+	}
+	return "", fmt.Errorf("Expected a \"literal\" or /regexp/")
+}
+
+// Make sure the specification is complete.
+func (s *spec) check(file string) error {
+	if s.pkg == "" {
+		return &specError{file, 1, "Missing package"}
+	}
+	if len(s.modes) == 0 {
+		return &specError{file, 1, "No modes"}
+	}
+	for _, m := range s.modes {
+		if len(m.rules) == 0 {
+			return &specError{file, m.line, fmt.Sprintf("Mode %q has no rules", m.name)}
+		}
+		for _, ru := range m.rules {
+			if ru.push != "" && s.mode(ru.push) == nil {
+				return &specError{file, ru.line, fmt.Sprintf("Unknown mode %q", ru.push)}
+			}
+		}
+	}
+	return s.checkNames(file)
+}
+
+// Identifiers that the generated code declares or refers to itself.
+var reservedNames = []string{
+	"TokenType", "lexer", "strconv", "string", "int", "iota", "nil",
+	"l", "start", "best", "length", "end", "typ",
+}
+
+// Make sure the identifiers in the generated code do not collide.
+func (s *spec) checkNames(file string) error {
+	used := make(map[string]string)
+	for _, name := range reservedNames {
+		used[name] = "the generated code"
+	}
+	for _, m := range s.modes {
+		name := stateName(m)
+		if by, ok := used[name]; ok {
+			return &specError{file, m.line, fmt.Sprintf("Mode %q generates %q, which is already used by %s", m.name, name, by)}
+		}
+		used[name] = fmt.Sprintf("mode %q", m.name)
+		for i, ru := range m.rules {
+			token := fmt.Sprintf("token %q", ru.token)
+			if by, ok := used[ru.token]; ok && ru.token != "" && by != token {
+				return &specError{file, ru.line, fmt.Sprintf("Token %q is already used by %s", ru.token, by)}
+			}
+			if ru.token != "" {
+				used[ru.token] = token
+			}
+			if ru.regexp == "" {
+				continue
+			}
+			name := regexpName(m, i)
+			if by, ok := used[name]; ok {
+				return &specError{file, ru.line, fmt.Sprintf("Regular expression generates %q, which is already used by %s", name, by)}
+			}
+			used[name] = "a regular expression"
+		}
+	}
+	return nil
+}
+
+// Return the mode with the given name, or nil.
+func (s *spec) mode(name string) *mode {
+	for _, m := range s.modes {
+		if m.name == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// Split str at the first run of whitespace.
+func cut(str string) (string, string) {
+	if i := strings.IndexAny(str, " \t"); i >= 0 {
+		return str[:i], strings.TrimSpace(str[i:])
+	}
+	return str, ""
+}
@@ -0,0 +1,25 @@
+package lexer
+
+// Accept a comment from open to close, in which further comments from open
+// to close may be nested, as in /* a /* b */ c */.
+// If the input does not start with open, ScanNone is returned.
+// If the outermost comment is not closed, an error naming the line it started
+// on is emitted at the end of the input, and ScanError is returned.
+// When open and close are the same, comments cannot nest.
+func (l *LexInner) NestedComment(open, close string) Scan {
+	start := l.Mark()
+	if !l.String(open) {
+		return ScanNone
+	}
+	for depth := 1; depth > 0; {
+		switch {
+		case l.String(close):
+			depth--
+		case l.String(open):
+			depth++
+		case l.Next() == Eof:
+			return l.errorf("Unterminated comment starting on line %d", start.Line())
+		}
+	}
+	return ScanOK
+}
@@ -0,0 +1,92 @@
+package lexer_test
+
+import (
+	"testing"
+
+	"github.com/PieterD/lexer"
+)
+
+func commentState(open, close string) lexer.StateFn {
+	return func(l *lexer.LexInner) lexer.StateFn {
+		l.Run(func(r rune) bool { return r != rune(open[0]) && r != lexer.Eof })
+		l.Ignore()
+		switch l.NestedComment(open, close) {
+		case lexer.ScanNone:
+			return l.Errorf("Not a comment")
+		case lexer.ScanError:
+			return nil
+		}
+		l.Emit(TokenSymbol)
+		return nil
+	}
+}
+
+func TestNestedComment(t *testing.T) {
+	tests := []struct {
+		open, close string
+		input       string
+		val         string
+		line        int
+	}{
+		{"/*", "*/", "/* a */ b */", "/* a */", 1},
+		{"/*", "*/", "x /* a /* b */ c */ d */", "/* a /* b */ c */", 1},
+		{"/*", "*/", "x\n/* a\n/* b\n*/\n*/ d", "/* a\n/* b\n*/\n*/", 2},
+		{"/*", "*/", "/*/ a */", "/*/ a */", 1},
+		{"{-", "-}", "{- {- -} -}-}", "{- {- -} -}", 1},
+		{`"""`, `"""`, `""" a """ b """`, `""" a """`, 1},
+	}
+	for _, test := range tests {
+		token := lexer.New("test", test.input, commentState(test.open, test.close)).Iterate().Token()
+		if token.Typ != TokenSymbol || token.Val != test.val || token.Line != test.line {
+			t.Fatalf("%q: expected %q on line %d, got %#v", test.input, test.val, test.line, token)
+		}
+	}
+}
+
+func TestNestedCommentErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+		line  int
+	}{
+		{"x", "Not a comment", 1},
+		{"/* a", "Unterminated comment starting on line 1", 1},
+		{"x\n/* a\n/* b */\nc", "Unterminated comment starting on line 2", 4},
+		{"/* a /* b\n/* c */ */", "Unterminated comment starting on line 1", 2},
+	}
+	for _, test := range tests {
+		token := lexer.New("test", test.input, commentState("/*", "*/")).Iterate().Token()
+		if token.Typ != lexer.TokenError || token.Val != test.err || token.Line != test.line {
+			t.Fatalf("%q: expected error %q on line %d, got %#v", test.input, test.err, test.line, token)
+		}
+	}
+}
+
+func TestNestedComments(t *testing.T) {
+	var state lexer.StateFn
+	state = func(l *lexer.LexInner) lexer.StateFn {
+		l.AcceptRun(" \n")
+		l.Ignore()
+		if l.Eof() {
+			return l.EmitEof()
+		}
+		if l.NestedComment("/*", "*/") != lexer.ScanOK {
+			return nil
+		}
+		l.Emit(TokenSymbol)
+		return state
+	}
+	tokens := []lexer.Token{
+		{Typ: TokenSymbol, Val: "/* a */", Line: 1, Column: 1},
+		{Typ: TokenSymbol, Val: "/* b /* c */\n*/", Line: 1, Column: 9},
+		{Typ: TokenSymbol, Val: "/**/", Line: 2, Column: 4},
+		{Typ: lexer.TokenError, Val: "Unterminated comment starting on line 3", Line: 3, Column: 5},
+	}
+	it := lexer.New("test", "/* a */ /* b /* c */\n*/ /**/\n/* d", state).Iterate()
+	for i, exp := range tokens {
+		token := it.Token()
+		if token.Typ != exp.Typ || token.Val != exp.Val || token.Line != exp.Line || token.Column != exp.Column {
+			t.Fatalf("Token %d: expected %#v, got %#v", i, exp, token)
+		}
+	}
+}
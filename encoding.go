@@ -0,0 +1,207 @@
+package lexer
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is the character encoding of the input given to NewEncoded.
+type Encoding int
+
+const (
+	// Detect the encoding from the byte order mark,
+	// and assume UTF-8 if there is none.
+	AutoEncoding Encoding = iota
+	UTF8
+	UTF16LE
+	UTF16BE
+	// ISO-8859-1, where every byte is the code point of the same value.
+	Latin1
+)
+
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// The number of decoded bytes between offsets recorded by a transcoding.
+const checkpointInterval = 256
+
+// Create a new lexer for input in the given encoding.
+// A byte order mark matching the encoding is stripped; with AutoEncoding,
+// it also determines the encoding. Input that is not UTF-8 is decoded to
+// UTF-8 first, so that LexInner sees the same text regardless of encoding.
+// Lines and columns are unaffected by this, and the Offset, Pos and End of
+// tokens refer to the original input rather than the decoded text.
+// UTF-8 input is lexed in place, like NewBytes.
+func NewEncoded(name string, input []byte, enc Encoding, start_state StateFn) *Lexer {
+	enc, bom := detectEncoding(input, enc)
+	input = input[bom:]
+	var ln *Lexer
+	switch enc {
+	case UTF16LE, UTF16BE, Latin1:
+		text, tr := transcode(input, enc, bom)
+		ln = newLexer(name, start_state)
+		ln.lexer.input = text
+		ln.lexer.tr = tr
+		ln.register()
+	default:
+		ln = NewBytes(name, input, start_state)
+		if bom > 0 {
+			ln.lexer.tr = &transcoding{bom: bom}
+		}
+	}
+	return ln
+}
+
+// Determine the encoding and the length of the byte order mark.
+func detectEncoding(input []byte, enc Encoding) (Encoding, int) {
+	boms := []struct {
+		enc Encoding
+		bom []byte
+	}{
+		{UTF8, bomUTF8},
+		{UTF16LE, bomUTF16LE},
+		{UTF16BE, bomUTF16BE},
+	}
+	for _, b := range boms {
+		if (enc == AutoEncoding || enc == b.enc) && bytes.HasPrefix(input, b.bom) {
+			return b.enc, len(b.bom)
+		}
+	}
+	if enc == AutoEncoding {
+		enc = UTF8
+	}
+	return enc, 0
+}
+
+// Decode the input to UTF-8.
+func transcode(input []byte, enc Encoding, bom int) (string, *transcoding) {
+	var buf strings.Builder
+	buf.Grow(len(input))
+	tr := &transcoding{bom: bom, width: latin1Width}
+	if enc != Latin1 {
+		tr.width = utf16Width
+	}
+	orig := bom
+	for len(input) > 0 {
+		if buf.Len() >= len(tr.points)*checkpointInterval {
+			tr.points = append(tr.points, offsetPair{buf.Len(), orig})
+		}
+		var r rune
+		size := 1
+		switch enc {
+		case Latin1:
+			r = rune(input[0])
+		default:
+			r, size = decodeUTF16(input, enc)
+		}
+		buf.WriteRune(r)
+		input = input[size:]
+		orig += size
+		if size != tr.width(r) {
+			// Like an odd byte at the end of UTF-16 input; the offsets that
+			// follow cannot be derived from the decoded rune.
+			tr.points = append(tr.points, offsetPair{buf.Len(), orig})
+		}
+	}
+	tr.text = buf.String()
+	return tr.text, tr
+}
+
+// Decode a single UTF-16 character, returning it and its size in bytes.
+func decodeUTF16(input []byte, enc Encoding) (rune, int) {
+	unit := func(i int) rune {
+		if enc == UTF16LE {
+			return rune(input[i]) | rune(input[i+1])<<8
+		}
+		return rune(input[i])<<8 | rune(input[i+1])
+	}
+	if len(input) < 2 {
+		return utf8.RuneError, len(input)
+	}
+	r := unit(0)
+	if utf16.IsSurrogate(r) && len(input) >= 4 {
+		if r2 := utf16.DecodeRune(r, unit(2)); r2 != utf8.RuneError {
+			return r2, 4
+		}
+	}
+	if utf16.IsSurrogate(r) {
+		return utf8.RuneError, 2
+	}
+	return r, 2
+}
+
+func latin1Width(r rune) int {
+	return 1
+}
+
+func utf16Width(r rune) int {
+	if r >= 0x10000 {
+		return 4
+	}
+	return 2
+}
+
+// A pair of corresponding offsets in the decoded and the original input.
+type offsetPair struct {
+	decoded  int
+	original int
+}
+
+// A transcoding maps offsets in the decoded input to the original input,
+// and back.
+type transcoding struct {
+	// The length of the byte order mark.
+	bom int
+	// The size of a decoded rune in the original input.
+	// If nil, the input was not decoded, and only the byte order mark was stripped.
+	width func(rune) int
+	// Corresponding offsets, every checkpointInterval decoded bytes.
+	points []offsetPair
+	text   string
+}
+
+// Return the offset in the original input for the given offset in the decoded input.
+func (tr *transcoding) original(pos int) int {
+	if tr.width == nil {
+		return pos + tr.bom
+	}
+	i := sort.Search(len(tr.points), func(i int) bool {
+		return tr.points[i].decoded > pos
+	})
+	if i == 0 {
+		return tr.bom
+	}
+	p := tr.points[i-1]
+	for p.decoded < pos && p.decoded < len(tr.text) {
+		r, size := utf8.DecodeRuneInString(tr.text[p.decoded:])
+		p.decoded += size
+		p.original += tr.width(r)
+	}
+	return p.original
+}
+
+// Return the offset in the decoded input for the given offset in the original input.
+func (tr *transcoding) decoded(orig int) int {
+	if tr.width == nil {
+		return orig - tr.bom
+	}
+	i := sort.Search(len(tr.points), func(i int) bool {
+		return tr.points[i].original > orig
+	})
+	if i == 0 {
+		return 0
+	}
+	p := tr.points[i-1]
+	for p.original < orig && p.decoded < len(tr.text) {
+		r, size := utf8.DecodeRuneInString(tr.text[p.decoded:])
+		p.decoded += size
+		p.original += tr.width(r)
+	}
+	return p.decoded
+}
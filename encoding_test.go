@@ -0,0 +1,82 @@
+package lexer_test
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/PieterD/lexer"
+)
+
+func encodeUTF16(text string, bigEndian bool) []byte {
+	var out []byte
+	for _, unit := range utf16.Encode([]rune(text)) {
+		if bigEndian {
+			out = append(out, byte(unit>>8), byte(unit))
+		} else {
+			out = append(out, byte(unit), byte(unit>>8))
+		}
+	}
+	return out
+}
+
+func TestEncodedUTF16(t *testing.T) {
+	text := strings.Repeat("\n", 200) + "föö = \"😀 x\";\nbar = 5;"
+	for _, bigEndian := range []bool{false, true} {
+		input := append([]byte{0xff, 0xfe}, encodeUTF16(text, false)...)
+		if bigEndian {
+			input = append([]byte{0xfe, 0xff}, encodeUTF16(text, true)...)
+		}
+		ln := lexer.NewEncoded("test", input, lexer.AutoEncoding, symbolState)
+		tokens := lexAll(ln)
+		str := tokens[2]
+		if str.Val != "\"😀 x\"" || str.Line != 201 || str.Column != 7 || str.Offset != 2+2*206 {
+			t.Fatalf("Unexpected token %#v", str)
+		}
+		if pos := ln.FileSet().Position(str.End); pos.Line != 201 || pos.Column != 12 || pos.Offset != 2+2*212 {
+			t.Fatalf("Unexpected end %s (%d)", pos, pos.Offset)
+		}
+		if bar := tokens[4]; bar.Val != "bar" || bar.Line != 202 || bar.Offset != 2+2*214 {
+			t.Fatalf("Unexpected token %#v", bar)
+		}
+	}
+}
+
+func TestEncodedLatin1(t *testing.T) {
+	ln := lexer.NewEncoded("test", []byte("caf\xe9 = \"\xe0\";"), lexer.Latin1, symbolState)
+	tokens := lexAll(ln)
+	if tokens[0].Val != "café" || tokens[2].Val != "\"à\"" || tokens[2].Offset != 7 || tokens[2].Column != 8 {
+		t.Fatalf("Unexpected tokens %#v", tokens)
+	}
+	if pos := ln.FileSet().Position(tokens[2].End); pos.Column != 11 || pos.Offset != 10 {
+		t.Fatalf("Unexpected end %s (%d)", pos, pos.Offset)
+	}
+}
+
+func TestEncodedUTF8(t *testing.T) {
+	ln := lexer.NewEncoded("test", []byte("\xef\xbb\xbfa = 1;"), lexer.AutoEncoding, symbolState)
+	tokens := lexAll(ln)
+	if tokens[0].Val != "a" || tokens[0].Column != 1 || tokens[0].Offset != 3 {
+		t.Fatalf("Unexpected token %#v", tokens[0])
+	}
+	ln = lexer.NewEncoded("test", []byte("\xef\xbb\xbfa = 1;"), lexer.Latin1, symbolState)
+	if tokens = lexAll(ln); tokens[0].Val != "ï»¿a" {
+		t.Fatalf("Unexpected token %#v", tokens[0])
+	}
+}
+
+func TestEncodedUTF16OddLength(t *testing.T) {
+	state := func(l *lexer.LexInner) lexer.StateFn {
+		l.Run(func(r rune) bool { return r != lexer.Eof })
+		l.Emit(TokenSymbol)
+		return l.EmitEof()
+	}
+	ln := lexer.NewEncoded("test", []byte{0xff, 0xfe, 'a', 0, 'b'}, lexer.AutoEncoding, state)
+	tokens := lexAll(ln)
+	if len(tokens) != 2 || tokens[0].Val != "a�" || tokens[1].Typ != lexer.TokenEOF || tokens[1].Offset != 5 {
+		t.Fatalf("Unexpected tokens %#v", tokens)
+	}
+	if pos := ln.FileSet().Position(tokens[0].End); pos.Offset != 5 || pos.Column != 3 {
+		t.Fatalf("Unexpected end %s (%d)", pos, pos.Offset)
+	}
+}
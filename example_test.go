@@ -2,6 +2,7 @@ package lexer_test
 
 import (
 	"fmt"
+	"strings"
 	"unicode"
 
 	"github.com/PieterD/lexer"
@@ -35,7 +36,7 @@ string = "Hello world!"
 	// filename:5 [2]"string"
 	// filename:5 [3]"="
 	// filename:5 [5]""Hello world!""
-	// filename:5 [-3]"EOF"
+	// filename:6 [-3]"EOF"
 }
 
 // Start parsing with this.
@@ -142,3 +143,19 @@ func state_string(l *lexer.LexInner) lexer.StateFn {
 		}
 	}
 }
+
+func ExampleLexInner_Errorf() {
+	line := "\tpie = 3.14"
+	l := lexer.New("filename", line, state_base).SetTabWidth(4)
+	for token := range l.Go() {
+		if token.Typ == lexer.TokenError {
+			// Draw a caret under the error, using the visual column.
+			fmt.Printf("%s:%d:%d: %s\n", token.File, token.Line, token.Column, token.Val)
+			fmt.Println(strings.ReplaceAll(line, "\t", "    "))
+			fmt.Println(strings.Repeat(" ", token.VColumn-1) + "^")
+		}
+	}
+	// Output: filename:1:9: Invalid variable name
+	//     pie = 3.14
+	//            ^
+}
@@ -0,0 +1,38 @@
+package lexer
+
+import (
+	"io"
+	"os"
+	"unsafe"
+)
+
+// Create a new lexer which lexes the file at the given path,
+// using the path as the File of its tokens.
+// Regular files are memory-mapped where possible, rather than read into memory.
+// The mapping is released once lexing is done, or when Close is called.
+// The file must not be modified while it is being lexed.
+// Other files, such as pipes, are read into memory first.
+func NewFile(path string, start_state StateFn) (*Lexer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode().IsRegular() && info.Size() > 0 {
+		data, unmap, err := mmap(f, info.Size())
+		if err == nil && data != nil {
+			ln := NewBytes(path, data, start_state)
+			ln.lexer.closer = unmap
+			return ln, nil
+		}
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	return New(path, unsafe.String(unsafe.SliceData(data), len(data)), start_state), nil
+}
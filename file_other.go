@@ -0,0 +1,11 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package lexer
+
+import "os"
+
+// Memory mapping is not supported on this platform;
+// NewFile falls back to reading the file.
+func mmap(f *os.File, size int64) ([]byte, func() error, error) {
+	return nil, nil, nil
+}
@@ -0,0 +1,79 @@
+package lexer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/PieterD/lexer"
+)
+
+func writeFile(t *testing.T, text string) string {
+	path := filepath.Join(t.TempDir(), "input.txt")
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	return path
+}
+
+func TestNewFile(t *testing.T) {
+	path := writeFile(t, "foo = 500;\nbar = \"baz\";\n")
+	ln, err := lexer.NewFile(path, symbolState)
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+	var tokens []lexer.Token
+	for token := range ln.Go() {
+		tokens = append(tokens, token)
+	}
+	if len(tokens) != 9 {
+		t.Fatalf("Expected 9 tokens, got %d", len(tokens))
+	}
+	if tokens[6].Val != `"baz"` || tokens[6].File != path || tokens[6].Line != 2 {
+		t.Fatalf("Unexpected token %#v", tokens[6])
+	}
+	if tokens[8].Typ != lexer.TokenEOF {
+		t.Fatalf("Expected EOF, got %#v", tokens[8])
+	}
+}
+
+func TestNewFileEmpty(t *testing.T) {
+	ln, err := lexer.NewFile(writeFile(t, ""), symbolState)
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+	if token := ln.Iterate().Token(); token.Typ != lexer.TokenEOF {
+		t.Fatalf("Expected EOF, got %#v", token)
+	}
+}
+
+func TestNewFileMissing(t *testing.T) {
+	_, err := lexer.NewFile(filepath.Join(t.TempDir(), "missing"), symbolState)
+	if err == nil {
+		t.Fatalf("Expected error for missing file")
+	}
+}
+
+func TestClose(t *testing.T) {
+	path := writeFile(t, "a=1;b=2;c=3;d=4;")
+	ln, _ := lexer.NewFile(path, symbolState)
+	it := ln.Iterate()
+	if token := it.Token(); token.Val != "a" {
+		t.Fatalf("Unexpected token %#v", token)
+	}
+	if err := ln.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if token := it.Token(); token.Typ != lexer.TokenEmpty {
+		t.Fatalf("Expected empty token after Close, got %#v", token)
+	}
+
+	ln, _ = lexer.NewFile(path, symbolState)
+	tokens := ln.Go()
+	if token := <-tokens; token.Val != "a" {
+		t.Fatalf("Unexpected token %#v", token)
+	}
+	ln.Close()
+	for range tokens {
+	}
+}
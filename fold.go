@@ -0,0 +1,66 @@
+package lexer
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Return true if a and b are equal under simple Unicode case folding.
+func equalFold(a, b rune) bool {
+	if a == b {
+		return true
+	}
+	for r := unicode.SimpleFold(a); r != a; r = unicode.SimpleFold(r) {
+		if r == b {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the length in bytes of the start of str that is equal to prefix
+// under simple Unicode case folding, or -1 if it does not start with prefix.
+func prefixFold(str, prefix string) int {
+	i := 0
+	for _, p := range prefix {
+		if i >= len(str) {
+			return -1
+		}
+		char, size := utf8.DecodeRuneInString(str[i:])
+		if !equalFold(char, p) {
+			return -1
+		}
+		i += size
+	}
+	return i
+}
+
+// Like String, but case-insensitive, using simple Unicode case folding.
+// The input is accepted as it is, so Get and Emit keep its original casing.
+func (l *LexInner) StringFold(valid string) bool {
+	l.fill(utf8.RuneCountInString(valid) * utf8.UTFMax)
+	n := prefixFold(l.rest(), valid)
+	if n < 0 {
+		return false
+	}
+	l.accept(n)
+	return true
+}
+
+// Like Find, but case-insensitive, using simple Unicode case folding.
+func (l *LexInner) FindFold(valid string) bool {
+	max := utf8.RuneCountInString(valid) * utf8.UTFMax
+	for i := 0; ; {
+		l.fill(i + max)
+		rest := l.rest()
+		if prefixFold(rest[i:], valid) >= 0 {
+			l.accept(i)
+			return true
+		}
+		if i >= len(rest) {
+			return false
+		}
+		_, size := utf8.DecodeRuneInString(rest[i:])
+		i += size
+	}
+}
@@ -0,0 +1,35 @@
+package lexer
+
+import "testing"
+
+func TestStringFold(t *testing.T) {
+	l := New("test", "SeLeCt\n*  FROM ΣΊΣΥΦΟΣ where", nil).lexer
+	if l.StringFold("selectx") {
+		t.Fatalf("StringFold succeeded on a longer string")
+	}
+	if !l.StringFold("select\n") || l.Get() != "SeLeCt\n" || l.mark.line != 2 || l.mark.col != 1 {
+		t.Fatalf("StringFold failed")
+	}
+	l.Ignore()
+	if !l.FindFold("from") || l.Get() != "*  " {
+		t.Fatalf("FindFold failed, got %q", l.Get())
+	}
+	if !l.StringFold("from σίσυφος") || l.mark.col != 16 {
+		t.Fatalf("StringFold failed on non-ASCII input")
+	}
+	l.Ignore()
+	if !l.FindFold("WHERE") || l.Get() != " " {
+		t.Fatalf("FindFold failed, got %q", l.Get())
+	}
+	if l.FindFold("missing") || l.Get() != " " {
+		t.Fatalf("FindFold succeeded on missing string")
+	}
+}
+
+func TestStringFoldKelvin(t *testing.T) {
+	// The Kelvin sign folds to 'k', but is three bytes long.
+	l := New("test", "\u212Aelvin", nil).lexer
+	if !l.StringFold("KELVIN") || l.Len() != 8 || !l.Eof() {
+		t.Fatalf("StringFold failed on Kelvin sign")
+	}
+}
@@ -1,7 +1,9 @@
 package lexer
 
 import (
+	"strings"
 	"testing"
+	"testing/iotest"
 	"unicode/utf8"
 )
 
@@ -245,3 +247,85 @@ func TestBytes(t *testing.T) {
 		t.Fatalf("Expected 'helloworld', got '%s'", s)
 	}
 }
+
+func TestPosition(t *testing.T) {
+	ln := New("test", "ab\nçd\nef", nil)
+	l := ln.lexer
+	check := func(line, col, offset int) {
+		t.Helper()
+		mark := l.Mark()
+		if mark.Line() != line || mark.Column() != col || mark.Offset() != offset {
+			t.Fatalf("Expected %d:%d@%d, got %d:%d@%d", line, col, offset, mark.Line(), mark.Column(), mark.Offset())
+		}
+	}
+	check(1, 1, 0)
+	l.Next()
+	l.Next()
+	check(1, 3, 2)
+	start := l.Mark()
+	l.Next()
+	check(2, 1, 3)
+	l.Back()
+	check(1, 3, 2)
+	l.Next()
+	l.Next()
+	check(2, 2, 5)
+	l.Unmark(start)
+	check(1, 3, 2)
+	if !l.String("\nçd\ne") {
+		t.Fatalf("String failed")
+	}
+	check(3, 2, 8)
+	l.Back()
+	check(1, 3, 2)
+	if !l.Find("d") {
+		t.Fatalf("Find failed")
+	}
+	check(2, 2, 5)
+	if !l.Bytes(2) {
+		t.Fatalf("Bytes failed")
+	}
+	check(3, 1, 7)
+	l.Retry()
+	check(1, 1, 0)
+	l.ExceptRun("")
+	check(3, 3, 9)
+	if l.Peek() != Eof {
+		t.Fatalf("Expected Eof")
+	}
+	check(3, 3, 9)
+}
+
+func TestPeekN(t *testing.T) {
+	for _, ln := range []*Lexer{
+		New("test", "aé\xffb", nil),
+		NewReader("test", iotest.OneByteReader(strings.NewReader("aé\xffb")), nil),
+	} {
+		l := ln.lexer
+		if l.Next() != 'a' {
+			t.Fatalf("Next returned wrong character")
+		}
+		mark := l.Mark()
+		for n, char := range []rune{'é', Err, 'b', Eof, Eof} {
+			if got := l.PeekN(n + 1); got != char {
+				t.Fatalf("PeekN(%d): expected %q, got %q", n+1, char, got)
+			}
+		}
+		if s := l.PeekString(2); s != "é\xff" {
+			t.Fatalf("PeekString(2): expected %q, got %q", "é\xff", s)
+		}
+		if s := l.PeekString(10); s != "é\xffb" {
+			t.Fatalf("PeekString(10): expected %q, got %q", "é\xffb", s)
+		}
+		if !l.HasPrefix("é\xff") || l.HasPrefix("é\xffbc") || !l.HasPrefix("") {
+			t.Fatalf("HasPrefix failed")
+		}
+		if l.mark != mark {
+			t.Fatalf("Lookahead moved the mark")
+		}
+		l.Back()
+		if l.Next() != 'a' {
+			t.Fatalf("Back after lookahead returned wrong character")
+		}
+	}
+}
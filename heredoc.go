@@ -0,0 +1,138 @@
+package lexer
+
+import "unicode"
+
+// A heredoc whose opener has been accepted, but whose body has not.
+type heredoc struct {
+	term string
+	// The character following <<, if it is - or ~.
+	kind rune
+	line int
+}
+
+// Return the characters that may precede the terminator of the heredoc.
+func (h heredoc) indent() string {
+	switch h.kind {
+	case '-':
+		return "\t"
+	case '~':
+		return " \t"
+	}
+	return ""
+}
+
+// Characters that make up an unquoted heredoc terminator.
+var heredocWord = Table(unicode.Letter).Union(decimalDigits, Chars("_"))
+
+// Accept a heredoc opener, like <<EOF, <<-EOF or <<'EOF', and remember its
+// terminator. The body starts on the next line, and is lexed by the state
+// returned by HeredocBody; the rest of the current line can be lexed as usual.
+// With <<-, as in shells, leading tabs are removed from every line of the body,
+// and the terminator may be indented with tabs. With <<~, as in Ruby, the common
+// indentation of the body is removed, and the terminator may be indented with
+// spaces and tabs.
+// If the input does not start with a heredoc opener, ScanNone is returned.
+// If a quoted terminator is not closed or empty, an error is emitted and
+// ScanError is returned.
+func (l *LexInner) Heredoc() Scan {
+	start := l.Mark()
+	if !l.String("<<") {
+		return ScanNone
+	}
+	var kind rune
+	if l.Accept("-~") {
+		kind = l.Last()
+	}
+	from := l.Len()
+	if quote := l.Peek(); quote == '\'' || quote == '"' {
+		l.Next()
+		from = l.Len()
+		l.ExceptRun(string(quote) + "\r\n")
+		term := l.Get()[from:]
+		if !l.Accept(string(quote)) {
+			return l.errorf("Unterminated heredoc delimiter")
+		}
+		if term == "" {
+			return l.errorf("Empty heredoc delimiter")
+		}
+		l.heredocs = append(l.heredocs, heredoc{term, kind, start.Line()})
+		return ScanOK
+	}
+	if l.AcceptSetRun(heredocWord) == 0 {
+		l.Unmark(start)
+		return ScanNone
+	}
+	l.heredocs = append(l.heredocs, heredoc{l.Get()[from:], kind, start.Line()})
+	return ScanOK
+}
+
+// Return a state that lexes the bodies of the heredocs opened on the current
+// line, emitting each as a token of the given type, followed by next.
+// Return it after accepting the line ending of every line that may have
+// opened a heredoc, and at the end of the input. If no heredoc is pending,
+// next is returned.
+// A body consists of the lines up to the one holding only the terminator,
+// which is skipped. Anything gathered for the current token is ignored.
+// If the input ends before the terminator, an error naming the line of the
+// opener is emitted.
+func (l *LexInner) HeredocBody(typ TokenType, next StateFn) StateFn {
+	if len(l.heredocs) == 0 {
+		return next
+	}
+	return func(*LexInner) StateFn {
+		h := l.heredocs[0]
+		l.heredocs = l.heredocs[1:]
+		l.Ignore()
+		body := l.Mark()
+		indent := -1
+		for !l.heredocEnd(h) {
+			if l.Eof() {
+				return l.Errorf("Unterminated heredoc %q starting on line %d", h.term, h.line)
+			}
+			mark := l.Mark()
+			n := l.AcceptRun(h.indent())
+			if h.kind == '-' && n > 0 {
+				l.Replace(mark, "")
+			}
+			if char := l.Peek(); char != '\r' && char != '\n' && char != Eof && (indent < 0 || n < indent) {
+				indent = n
+			}
+			l.skipLine()
+		}
+		if h.kind == '~' && indent > 0 {
+			l.Unmark(body)
+			for !l.heredocEnd(h) {
+				mark := l.Mark()
+				for n := 0; n < indent && l.Accept(" \t"); n++ {
+				}
+				l.Replace(mark, "")
+				l.skipLine()
+			}
+		}
+		l.Emit(typ)
+		l.AcceptRun(h.indent())
+		l.String(h.term)
+		l.skipLine()
+		l.Ignore()
+		return l.HeredocBody(typ, next)
+	}
+}
+
+// Return true if the current line holds only the terminator of the heredoc.
+func (l *LexInner) heredocEnd(h heredoc) bool {
+	mark := l.Mark()
+	defer l.Unmark(mark)
+	l.AcceptRun(h.indent())
+	if !l.String(h.term) {
+		return false
+	}
+	char := l.Peek()
+	return char == '\r' || char == '\n' || char == Eof
+}
+
+// Accept the rest of the line, including its line ending.
+func (l *LexInner) skipLine() {
+	l.ExceptRun("\r\n")
+	l.Accept("\r")
+	l.Accept("\n")
+}
@@ -0,0 +1,95 @@
+package lexer_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/PieterD/lexer"
+)
+
+const (
+	tokenShellWord lexer.TokenType = 1 + iota
+	tokenHeredoc
+	tokenBody
+	tokenLineEnd
+)
+
+func shellLine(l *lexer.LexInner) lexer.StateFn {
+	l.AcceptRun(" \t")
+	l.Ignore()
+	switch l.Heredoc() {
+	case lexer.ScanOK:
+		l.Emit(tokenHeredoc)
+		return shellLine
+	case lexer.ScanError:
+		return nil
+	}
+	switch char := l.Peek(); {
+	case char == lexer.Eof:
+		return l.HeredocBody(tokenBody, indentEnd)
+	case char == '\n':
+		l.Next()
+		l.Emit(tokenLineEnd)
+		return l.HeredocBody(tokenBody, shellLine)
+	}
+	l.ExceptRun(" \t\n")
+	l.Emit(tokenShellWord)
+	return shellLine
+}
+
+func lexShell(input string) string {
+	var got []string
+	for _, token := range lexAll(lexer.New("test", input, shellLine)) {
+		switch token.Typ {
+		case tokenBody:
+			got = append(got, fmt.Sprintf("%d:%q", token.Line, token.Val))
+		case tokenLineEnd:
+			got = append(got, "NL")
+		default:
+			got = append(got, token.Val)
+		}
+	}
+	return strings.Join(got, " ")
+}
+
+func TestHeredoc(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"cat <<EOF\nhello\n  world\nEOF\necho", `cat <<EOF NL 2:"hello\n  world\n" echo EOF`},
+		{"cat <<EOF | grep x\nEOF x\nEOF", `cat <<EOF | grep x NL 2:"EOF x\n" EOF`},
+		{"a <<'END' b <<\"X Y\"\none\nEND\ntwo\nX Y\nc\n", `a <<'END' b <<"X Y" NL 2:"one\n" 4:"two\n" c NL EOF`},
+		{"a <<-EOF\n\t\tx\n\ty\n  z\n\tEOF\nb", `a <<-EOF NL 2:"x\ny\n  z\n" b EOF`},
+		{"a <<-EOF\nx\n  EOF\nEOF\n", `a <<-EOF NL 2:"x\n  EOF\n" EOF`},
+		{"a <<~EOF\n    x\n\n      y\n  EOF\nb", `a <<~EOF NL 2:"x\n\n  y\n" b EOF`},
+		{"a <<~EOF\n\tx\n\t\ty\n\tEOF\n", `a <<~EOF NL 2:"x\n\ty\n" EOF`},
+		{"a <<EOF\nEOF\n", `a <<EOF NL 2:"" EOF`},
+		{"a << b\n", `a << b NL EOF`},
+	}
+	for _, test := range tests {
+		if got := lexShell(test.input); got != test.expected {
+			t.Fatalf("%q: expected %s, got %s", test.input, test.expected, got)
+		}
+	}
+}
+
+func TestHeredocErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"cat <<EOF\nhello\n  EOF\n", `Unterminated heredoc "EOF" starting on line 1`},
+		{"cat\n<<EOF", `Unterminated heredoc "EOF" starting on line 2`},
+		{"cat <<'EOF\nx\nEOF", "Unterminated heredoc delimiter"},
+		{"cat <<''\n", "Empty heredoc delimiter"},
+	}
+	for _, test := range tests {
+		tokens := lexAll(lexer.New("test", test.input, shellLine))
+		last := tokens[len(tokens)-1]
+		if last.Typ != lexer.TokenError || last.Val != test.err {
+			t.Fatalf("%q: expected error %q, got %#v", test.input, test.err, last)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package lexer
+
+import "unicode"
+
+var (
+	// Characters that may start an identifier, as defined by the Unicode
+	// XID_Start property.
+	XIDStart = charFunc(func(char rune) bool {
+		return isIDStart(char) && !unicode.Is(xidStartExceptions, char)
+	})
+	// Characters that may continue an identifier, as defined by the Unicode
+	// XID_Continue property.
+	XIDContinue = charFunc(func(char rune) bool {
+		return (isIDStart(char) || unicode.In(char, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc, unicode.Other_ID_Continue)) &&
+			!unicode.In(char, unicode.Pattern_Syntax, unicode.Pattern_White_Space, xidContinueExceptions)
+	})
+)
+
+// The ID_Start property, which is derived from the general category.
+func isIDStart(char rune) bool {
+	return unicode.In(char, unicode.L, unicode.Nl, unicode.Other_ID_Start) &&
+		!unicode.In(char, unicode.Pattern_Syntax, unicode.Pattern_White_Space)
+}
+
+// Characters in ID_Continue but not in XID_Continue, because they
+// do not stay identifier characters under NFKC normalization.
+var xidContinueExceptions = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x037a, Hi: 0x037a, Stride: 1},
+		{Lo: 0x309b, Hi: 0x309c, Stride: 1},
+		{Lo: 0xfc5e, Hi: 0xfc63, Stride: 1},
+		{Lo: 0xfdfa, Hi: 0xfdfb, Stride: 1},
+		{Lo: 0xfe70, Hi: 0xfe7e, Stride: 2},
+	},
+}
+
+// Characters in ID_Start but not in XID_Start.
+var xidStartExceptions = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x037a, Hi: 0x037a, Stride: 1},
+		{Lo: 0x0e33, Hi: 0x0eb3, Stride: 0x80},
+		{Lo: 0x309b, Hi: 0x309c, Stride: 1},
+		{Lo: 0xfc5e, Hi: 0xfc63, Stride: 1},
+		{Lo: 0xfdfa, Hi: 0xfdfb, Stride: 1},
+		{Lo: 0xfe70, Hi: 0xfe7e, Stride: 2},
+		{Lo: 0xff9e, Hi: 0xff9f, Stride: 1},
+	},
+}
+
+// An IdentifierProfile determines which identifiers LexInner.Identifier accepts.
+// The zero value accepts the default identifiers of Unicode Standard Annex #31.
+type IdentifierProfile struct {
+	// Characters that may start an identifier in addition to XIDStart,
+	// like "_$". They may continue one as well.
+	ExtraStart string
+	// Characters that may continue an identifier in addition to XIDContinue,
+	// like "-".
+	ExtraContinue string
+	// If set, called with the identifier to check that it is in
+	// Normalization Form C, for instance with norm.NFC.IsNormalString
+	// from golang.org/x/text/unicode/norm.
+	IsNFC func(string) bool
+}
+
+// Accept an identifier as allowed by the profile; a nil profile is the
+// same as the zero value.
+// If the input does not start with an identifier, ScanNone is returned.
+// If the identifier is not in Normalization Form C, an error is emitted at
+// its start and ScanError is returned.
+func (l *LexInner) Identifier(p *IdentifierProfile) Scan {
+	if p == nil {
+		p = &IdentifierProfile{}
+	}
+	start := l.Mark()
+	if !l.AcceptSet(XIDStart) && !l.Accept(p.ExtraStart) {
+		return ScanNone
+	}
+	for l.AcceptSet(XIDContinue) || l.Accept(p.ExtraStart) || l.Accept(p.ExtraContinue) {
+	}
+	if p.IsNFC != nil && !p.IsNFC(l.Get()[start.rpos():]) {
+		l.Unmark(start)
+		return l.errorf("Identifier is not in Normalization Form C")
+	}
+	return ScanOK
+}
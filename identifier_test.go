@@ -0,0 +1,105 @@
+package lexer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PieterD/lexer"
+)
+
+func identifierState(p *lexer.IdentifierProfile) lexer.StateFn {
+	return func(l *lexer.LexInner) lexer.StateFn {
+		switch l.Identifier(p) {
+		case lexer.ScanNone:
+			return l.Errorf("Not an identifier")
+		case lexer.ScanError:
+			return nil
+		}
+		l.Emit(TokenSymbol)
+		return nil
+	}
+}
+
+func TestIdentifier(t *testing.T) {
+	js := &lexer.IdentifierProfile{ExtraStart: "_$"}
+	lisp := &lexer.IdentifierProfile{ExtraContinue: "-?"}
+	tests := []struct {
+		profile *lexer.IdentifierProfile
+		input   string
+		val     string
+	}{
+		{nil, "café = 1", "café"},
+		{nil, "x+y", "x"},
+		{nil, "变量2 ", "变量2"},
+		{nil, "a_b", "a_b"},
+		{nil, "e\u0301té", "e\u0301té"},
+		{nil, "l·l", "l·l"},
+		{nil, "aﾞ", "aﾞ"},
+		{nil, "Ⅻx", "Ⅻx"},
+		{js, "_x$1.y", "_x$1"},
+		{js, "$", "$"},
+		{lisp, "empty-list? x", "empty-list?"},
+	}
+	for _, test := range tests {
+		token := lexer.New("test", test.input, identifierState(test.profile)).Iterate().Token()
+		if token.Typ != TokenSymbol || token.Val != test.val {
+			t.Fatalf("%q: expected %q, got %#v", test.input, test.val, token)
+		}
+	}
+}
+
+func TestIdentifierErrors(t *testing.T) {
+	nfc := &lexer.IdentifierProfile{IsNFC: func(s string) bool {
+		return !strings.ContainsRune(s, '\u0301')
+	}}
+	tests := []struct {
+		profile *lexer.IdentifierProfile
+		input   string
+		err     string
+	}{
+		{nil, "1abc", "Not an identifier"},
+		{nil, "_x", "Not an identifier"},
+		{nil, "゛x", "Not an identifier"},
+		{nil, "ﾞ", "Not an identifier"},
+		{nil, "-x", "Not an identifier"},
+		{nfc, "e\u0301", "Identifier is not in Normalization Form C"},
+	}
+	for _, test := range tests {
+		token := lexer.New("test", test.input, identifierState(test.profile)).Iterate().Token()
+		if token.Typ != lexer.TokenError || token.Val != test.err || token.Column != 1 {
+			t.Fatalf("%q: expected error %q, got %#v", test.input, test.err, token)
+		}
+	}
+	if token := lexer.New("test", "é", identifierState(nfc)).Iterate().Token(); token.Val != "é" {
+		t.Fatalf("Expected NFC identifier to be accepted, got %#v", token)
+	}
+}
+
+func TestIdentifiers(t *testing.T) {
+	var state lexer.StateFn
+	state = func(l *lexer.LexInner) lexer.StateFn {
+		l.AcceptRun(" \t\n")
+		l.Ignore()
+		if l.Eof() {
+			return l.EmitEof()
+		}
+		if l.Identifier(nil) != lexer.ScanOK {
+			return l.Errorf("Not an identifier")
+		}
+		l.Emit(TokenSymbol)
+		return state
+	}
+	tokens := []lexer.Token{
+		{Typ: TokenSymbol, Val: "café", Line: 1, Column: 1},
+		{Typ: TokenSymbol, Val: "变量2", Line: 1, Column: 6},
+		{Typ: TokenSymbol, Val: "x_1", Line: 2, Column: 2},
+		{Typ: lexer.TokenError, Val: "Not an identifier", Line: 2, Column: 6},
+	}
+	it := lexer.New("test", "café 变量2\n\tx_1 1b", state).Iterate()
+	for i, exp := range tokens {
+		token := it.Token()
+		if token.Typ != exp.Typ || token.Val != exp.Val || token.Line != exp.Line || token.Column != exp.Column {
+			t.Fatalf("Token %d: expected %#v, got %#v", i, exp, token)
+		}
+	}
+}
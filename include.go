@@ -0,0 +1,98 @@
+package lexer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The maximum number of inputs that may be included within each other.
+// If this is exceeded, Include reports an error.
+const MaxIncludeDepth = 64
+
+// A Resolver finds the input for LexInner.IncludeFile.
+// It is given the name of the including input and the name to include,
+// and returns the name of the included input along with its contents.
+// The returned name is used for the File of its tokens, and to detect cycles.
+type Resolver func(parent, name string) (path string, input string, err error)
+
+// FileResolver is the default Resolver.
+// It reads the named file, relative to the directory of the including file
+// unless the name is absolute.
+func FileResolver(parent, name string) (string, string, error) {
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(parent), path)
+	}
+	input, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+	return path, string(input), nil
+}
+
+// An input that is being included, with the state to return to.
+type include struct {
+	source source
+	mark   Mark
+}
+
+// Start lexing the given input, and return next.
+// Tokens will carry the given name as File, and the lines of the new input,
+// until its end is reached. Once the token at its end has been emitted or
+// ignored, lexing resumes in the including input. Marks made in the included
+// input cannot be recovered after that.
+// Anything gathered for the current token is ignored.
+// If name is already being included, or MaxIncludeDepth would be exceeded,
+// an error is emitted instead and Include returns nil.
+func (l *LexInner) Include(name string, input string, next StateFn) StateFn {
+	if len(l.includes) >= MaxIncludeDepth {
+		return l.Errorf("Include depth exceeds %d while including %q", MaxIncludeDepth, name)
+	}
+	if name == l.name {
+		return l.Errorf("Include cycle: %q includes itself", name)
+	}
+	for _, inc := range l.includes {
+		if inc.source.name == name {
+			return l.Errorf("Include cycle: %q includes itself", name)
+		}
+	}
+	l.ignore()
+	// The name may have been taken from input that is lexed in place.
+	name = strings.Clone(name)
+	l.includes = append(l.includes, include{l.source, l.mark})
+	l.source = source{name: name, input: input}
+	l.file = l.fset.AddFile(name)
+	l.file.setContent(input, nil)
+	l.mark = newMark()
+	return next
+}
+
+// Use the Resolver to find the input with the given name, and Include it.
+// If the Resolver fails, an error is emitted and IncludeFile returns nil.
+func (l *LexInner) IncludeFile(name string, next StateFn) StateFn {
+	path, input, err := l.resolver(l.name, name)
+	if err != nil {
+		return l.Errorf("Cannot include %q: %v", name, err)
+	}
+	return l.Include(path, input, next)
+}
+
+// Return to the including input for every included input whose end has been
+// reached, if nothing has been gathered for the current token.
+// This is only done between tokens, so that a Mark never refers to an input
+// that has been left.
+func (l *LexInner) popEnded() {
+	for len(l.includes) > 0 && l.Len() == 0 && !l.fill(1) {
+		l.pop()
+	}
+}
+
+// Return to the including input.
+func (l *LexInner) pop() {
+	l.source.release()
+	last := len(l.includes) - 1
+	l.source = l.includes[last].source
+	l.mark = l.includes[last].mark
+	l.includes = l.includes[:last]
+}
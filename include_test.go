@@ -0,0 +1,138 @@
+package lexer_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/PieterD/lexer"
+)
+
+const tokenWord lexer.TokenType = 1
+
+// Lexes words, and includes the file named after every "include".
+func includeState(l *lexer.LexInner) lexer.StateFn {
+	l.Run(unicode.IsSpace)
+	l.Ignore()
+	if l.Eof() {
+		return l.EmitEof()
+	}
+	if l.String("include ") {
+		l.Ignore()
+		l.ExceptRun(" \n")
+		return l.IncludeFile(l.Get(), includeState)
+	}
+	l.ExceptRun(" \n")
+	l.Emit(tokenWord)
+	return includeState
+}
+
+func mapResolver(files map[string]string) lexer.Resolver {
+	return func(parent, name string) (string, string, error) {
+		input, ok := files[name]
+		if !ok {
+			return "", "", fmt.Errorf("no such file")
+		}
+		return name, input, nil
+	}
+}
+
+func lexAll(ln *lexer.Lexer) []lexer.Token {
+	var tokens []lexer.Token
+	for token := range ln.Go() {
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+func TestInclude(t *testing.T) {
+	files := map[string]string{
+		"a": "one\ninclude b\nfour",
+		"b": "two\n\nthree",
+	}
+	ln := lexer.New("a", files["a"], includeState).SetResolver(mapResolver(files))
+	fset := ln.FileSet()
+	var got []string
+	for _, token := range lexAll(ln) {
+		got = append(got, fmt.Sprintf("%s %s:%d %s", token.Val, token.File, token.Line, fset.Position(token.Pos)))
+	}
+	expected := []string{
+		"one a:1 a:1:1",
+		"two b:1 b:1:1",
+		"three b:3 b:3:1",
+		"four a:3 a:3:1",
+		"EOF a:3 a:3:5",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Fatalf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestIncludeErrors(t *testing.T) {
+	files := map[string]string{
+		"a":    "include b",
+		"b":    "x include a",
+		"self": "include self",
+	}
+	tokens := lexAll(lexer.New("a", files["a"], includeState).SetResolver(mapResolver(files)))
+	last := tokens[len(tokens)-1]
+	if last.Typ != lexer.TokenError || last.Val != `Include cycle: "a" includes itself` || last.File != "b" || last.Column != 12 {
+		t.Fatalf("Expected cycle error, got %#v", last)
+	}
+	tokens = lexAll(lexer.New("self", files["self"], includeState).SetResolver(mapResolver(files)))
+	if tokens[0].Val != `Include cycle: "self" includes itself` {
+		t.Fatalf("Expected cycle error, got %#v", tokens[0])
+	}
+	tokens = lexAll(lexer.New("a", "include c", includeState).SetResolver(mapResolver(files)))
+	if tokens[0].Val != `Cannot include "c": no such file` {
+		t.Fatalf("Expected resolver error, got %#v", tokens[0])
+	}
+	deep := func(parent, name string) (string, string, error) {
+		return parent + "x", "include x", nil
+	}
+	tokens = lexAll(lexer.New("a", "include x", includeState).SetResolver(deep))
+	if tokens[0].Typ != lexer.TokenError || !strings.HasPrefix(tokens[0].Val, fmt.Sprintf("Include depth exceeds %d", lexer.MaxIncludeDepth)) {
+		t.Fatalf("Expected depth error, got %#v", tokens[0])
+	}
+}
+
+func TestIncludeFromFile(t *testing.T) {
+	inc := writeFile(t, "two")
+	ln, err := lexer.NewFile(writeFile(t, "one\ninclude "+inc+"\nthree"), includeState)
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+	fset := ln.FileSet()
+	tokens := lexAll(ln)
+	if len(tokens) != 4 || tokens[1].Val != "two" {
+		t.Fatalf("Unexpected tokens %v", tokens)
+	}
+	if tokens[1].File != inc || fset.Position(tokens[1].Pos).Filename != inc {
+		t.Fatalf("Expected file %q, got %q and %q", inc, tokens[1].File, fset.Position(tokens[1].Pos).Filename)
+	}
+}
+
+func TestIncludeMarkAtEnd(t *testing.T) {
+	rest := func(l *lexer.LexInner) lexer.StateFn {
+		if l.QuotedString(lexer.GoStrings) != lexer.ScanNone {
+			return l.Errorf("Unexpected string")
+		}
+		l.Run(func(r rune) bool { return r != lexer.Eof })
+		l.Emit(tokenWord)
+		return nil
+	}
+	included := func(l *lexer.LexInner) lexer.StateFn {
+		l.String("bb")
+		l.Emit(tokenWord)
+		return rest
+	}
+	state := func(l *lexer.LexInner) lexer.StateFn {
+		l.String("inc;")
+		return l.Include("b", "bb", included)
+	}
+	tokens := lexAll(lexer.New("a", "inc;zzzzzz", state))
+	if len(tokens) != 2 || tokens[0].Val != "bb" || tokens[1].Val != "zzzzzz" || tokens[1].File != "a" {
+		t.Fatalf("Unexpected tokens %#v", tokens)
+	}
+}
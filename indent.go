@@ -0,0 +1,98 @@
+package lexer
+
+import "strings"
+
+// An IndentProfile determines the tokens emitted by LexInner.Indentation.
+type IndentProfile struct {
+	// Emitted when a line is indented further than the previous one,
+	// with all leading whitespace of the line as its value.
+	Indent TokenType
+	// Emitted, with an empty value, for every level of indentation that ends.
+	Dedent TokenType
+	// Emitted for the line ending before a new logical line.
+	Newline TokenType
+	// Lines containing nothing but whitespace and a comment starting with
+	// this are skipped like blank lines. Leave empty if there are no comments.
+	Comment string
+}
+
+// Return a state that handles indentation at the start of a logical line,
+// followed by next. Return it at the start of the input, at each line ending,
+// and at the end of the input.
+// A line ending is emitted as a Newline token, unless it ends a blank line
+// at the start of the input. Blank lines and lines holding
+// only a comment are skipped. The indentation of the line is then compared
+// to the indentation levels of the lines before it, emitting an Indent token
+// if it is deeper, or a Dedent token for every level it ends if it is shallower.
+// Tabs advance to the next tab stop, as set with Lexer.SetTabWidth.
+// At the end of the input, all levels end.
+// An indentation that is shallower but does not match an earlier level is
+// reported as an error; one that mixes tabs and spaces as a warning.
+// Dedent tokens are emitted by separate states, so that Iterate can be used
+// regardless of how many levels end at once.
+func (l *LexInner) Indentation(p *IndentProfile, next StateFn) StateFn {
+	return func(*LexInner) StateFn {
+		first := l.mark.pos == 0
+		newline := l.Accept("\r")
+		if l.Accept("\n") || newline {
+			if first {
+				l.Ignore()
+			} else {
+				l.Emit(p.Newline)
+			}
+		}
+		for {
+			l.AcceptRun(" \t")
+			if p.Comment != "" && l.HasPrefix(p.Comment) {
+				l.ExceptRun("\r\n")
+			}
+			if char := l.Peek(); char != '\r' && char != '\n' {
+				break
+			}
+			l.Accept("\r")
+			l.Accept("\n")
+			l.Ignore()
+		}
+		indent := l.Get()
+		width := l.mark.vcol - 1
+		if l.Eof() {
+			width = 0
+		} else if strings.Contains(indent, " ") && strings.Contains(indent, "\t") {
+			l.Warningf("Indentation mixes tabs and spaces")
+		}
+		top := 0
+		if len(l.indents) > 0 {
+			top = l.indents[len(l.indents)-1]
+		}
+		if width > top {
+			l.indents = append(l.indents, width)
+			l.Emit(p.Indent)
+			return next
+		}
+		l.Ignore()
+		if width == top {
+			return next
+		}
+		match := width == 0
+		for _, level := range l.indents {
+			match = match || level == width
+		}
+		if !match {
+			return l.Errorf("Inconsistent dedent: indentation %d does not match any outer level", width)
+		}
+		return l.dedent(p, width, next)
+	}
+}
+
+// Return a state that emits a Dedent token for the innermost indentation level,
+// until the level with the given width is reached.
+func (l *LexInner) dedent(p *IndentProfile, width int, next StateFn) StateFn {
+	return func(*LexInner) StateFn {
+		l.indents = l.indents[:len(l.indents)-1]
+		l.EmitString(p.Dedent, "")
+		if len(l.indents) > 0 && l.indents[len(l.indents)-1] > width {
+			return l.dedent(p, width, next)
+		}
+		return next
+	}
+}
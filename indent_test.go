@@ -0,0 +1,113 @@
+package lexer_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/PieterD/lexer"
+)
+
+const (
+	tokenWordIndent lexer.TokenType = 1 + iota
+	tokenIndent
+	tokenDedent
+	tokenNewline
+)
+
+var pythonIndents = &lexer.IndentProfile{
+	Indent:  tokenIndent,
+	Dedent:  tokenDedent,
+	Newline: tokenNewline,
+	Comment: "#",
+}
+
+func indentStart(l *lexer.LexInner) lexer.StateFn {
+	return l.Indentation(pythonIndents, indentLine)
+}
+
+func indentEnd(l *lexer.LexInner) lexer.StateFn {
+	return l.EmitEof()
+}
+
+func indentLine(l *lexer.LexInner) lexer.StateFn {
+	l.AcceptRun(" \t")
+	l.Ignore()
+	switch char := l.Peek(); {
+	case char == lexer.Eof:
+		return l.Indentation(pythonIndents, indentEnd)
+	case char == '\r' || char == '\n':
+		return l.Indentation(pythonIndents, indentLine)
+	case char == '#':
+		l.ExceptRun("\r\n")
+		l.Ignore()
+	default:
+		l.ExceptRun(" \t\r\n#")
+		l.Emit(tokenWordIndent)
+	}
+	return indentLine
+}
+
+// Lex using Iterate, and describe the tokens.
+func iterateIndents(input string) string {
+	it := lexer.New("test", input, indentStart).Iterate()
+	var got []string
+	for {
+		token := it.Token()
+		switch token.Typ {
+		case lexer.TokenEmpty:
+			return strings.Join(got, " ")
+		case tokenIndent:
+			got = append(got, fmt.Sprintf("INDENT%q@%d:%d", token.Val, token.Line, token.Column))
+		case tokenDedent:
+			got = append(got, fmt.Sprintf("DEDENT@%d:%d", token.Line, token.Column))
+		case tokenNewline:
+			got = append(got, "NL")
+		case lexer.TokenError, lexer.TokenWarning:
+			got = append(got, fmt.Sprintf("%q@%d:%d", token.Val, token.Line, token.Column))
+		default:
+			got = append(got, token.Val)
+		}
+	}
+}
+
+func TestIndentation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a\nb", "a NL b EOF"},
+		{"if x\n  y\nz\n", `if x NL INDENT"  "@2:1 y NL DEDENT@3:1 z NL EOF`},
+		{"a\n  b\n\n    # comment\n\n  c # trailing\n", `a NL INDENT"  "@2:1 b NL c NL DEDENT@7:1 EOF`},
+		{"a\n\tb\n        c\r\nd", `a NL INDENT"\t"@2:1 b NL c NL DEDENT@4:1 d EOF`},
+		{"\n  # only\n  a\n", `INDENT"  "@3:1 a NL DEDENT@4:1 EOF`},
+		{"a\n  b\n", `a NL INDENT"  "@2:1 b NL DEDENT@3:1 EOF`},
+		{"a\n  b\n    c\n  d\n", `a NL INDENT"  "@2:1 b NL INDENT"    "@3:1 c NL DEDENT@4:3 d NL DEDENT@5:1 EOF`},
+		{"a\n \tb\n", `a NL "Indentation mixes tabs and spaces"@2:3 INDENT" \t"@2:1 b NL DEDENT@3:1 EOF`},
+		{"a\n    b\n  c\n", `a NL INDENT"    "@2:1 b NL "Inconsistent dedent: indentation 2 does not match any outer level"@3:3`},
+	}
+	for _, test := range tests {
+		if got := iterateIndents(test.input); got != test.expected {
+			t.Fatalf("%q: expected %s, got %s", test.input, test.expected, got)
+		}
+	}
+}
+
+func TestIndentationDeep(t *testing.T) {
+	var input strings.Builder
+	var expected []string
+	for i := 0; i < 2*lexer.MaxEmitsInFunction; i++ {
+		fmt.Fprintf(&input, "%sx\n", strings.Repeat(" ", i))
+		if i > 0 {
+			expected = append(expected, fmt.Sprintf("INDENT%q@%d:1", strings.Repeat(" ", i), i+1))
+		}
+		expected = append(expected, "x", "NL")
+	}
+	for i := 1; i < 2*lexer.MaxEmitsInFunction; i++ {
+		expected = append(expected, fmt.Sprintf("DEDENT@%d:1", 2*lexer.MaxEmitsInFunction+1))
+	}
+	expected = append(expected, "EOF")
+	if got := iterateIndents(input.String()); got != strings.Join(expected, " ") {
+		t.Fatalf("Expected %s, got %s", strings.Join(expected, " "), got)
+	}
+}
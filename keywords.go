@@ -0,0 +1,109 @@
+package lexer
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// Keywords is a compiled table of keywords or operators, for use with
+// LexInner.Keyword. It is safe for concurrent use once created.
+type Keywords struct {
+	root     kwNode
+	boundary func(rune) bool
+}
+
+// A node in the trie of keywords, reached through the bytes of a prefix.
+type kwNode struct {
+	bytes    []byte
+	children []*kwNode
+	typ      TokenType
+	terminal bool
+}
+
+// Create a table of keywords from a map of keyword to TokenType.
+func NewKeywords(words map[string]TokenType) *Keywords {
+	k := new(Keywords)
+	for word, typ := range words {
+		node := &k.root
+		for i := 0; i < len(word); i++ {
+			node = node.add(word[i])
+		}
+		node.typ = typ
+		node.terminal = true
+	}
+	return k
+}
+
+// Return the child for the given byte, or nil.
+func (n *kwNode) child(b byte) *kwNode {
+	i := sort.Search(len(n.bytes), func(i int) bool {
+		return n.bytes[i] >= b
+	})
+	if i < len(n.bytes) && n.bytes[i] == b {
+		return n.children[i]
+	}
+	return nil
+}
+
+// Return the child for the given byte, creating it if needed.
+func (n *kwNode) add(b byte) *kwNode {
+	i := sort.Search(len(n.bytes), func(i int) bool {
+		return n.bytes[i] >= b
+	})
+	if i < len(n.bytes) && n.bytes[i] == b {
+		return n.children[i]
+	}
+	child := new(kwNode)
+	n.bytes = append(n.bytes, 0)
+	copy(n.bytes[i+1:], n.bytes[i:])
+	n.bytes[i] = b
+	n.children = append(n.children, nil)
+	copy(n.children[i+1:], n.children[i:])
+	n.children[i] = child
+	return child
+}
+
+// Require keywords ending in a word character to be followed by a
+// non-word character (or Eof), so that "iffy" is not lexed as "if".
+// isWord decides which characters are word characters.
+// Keywords ending in other characters, such as operators, are not affected.
+// Returns the Keywords, for chaining with NewKeywords.
+func (k *Keywords) WordBoundary(isWord func(rune) bool) *Keywords {
+	k.boundary = isWord
+	return k
+}
+
+// Accept the longest keyword at the current position, and return its TokenType.
+// If none match, nothing is accepted and it returns false.
+func (l *LexInner) Keyword(k *Keywords) (TokenType, bool) {
+	type candidate struct {
+		length int
+		typ    TokenType
+	}
+	var candidates []candidate
+	node := &k.root
+	for i := 0; l.fill(i + 1); i++ {
+		node = node.child(l.rest()[i])
+		if node == nil {
+			break
+		}
+		if node.terminal {
+			candidates = append(candidates, candidate{i + 1, node.typ})
+		}
+	}
+	for i := len(candidates) - 1; i >= 0; i-- {
+		c := candidates[i]
+		if k.boundary != nil {
+			l.fill(c.length + utf8.UTFMax)
+			rest := l.rest()
+			last, _ := utf8.DecodeLastRuneInString(rest[:c.length])
+			next, size := utf8.DecodeRuneInString(rest[c.length:])
+			if size > 0 && k.boundary(last) && k.boundary(next) {
+				continue
+			}
+		}
+		l.accept(c.length)
+		return c.typ, true
+	}
+	return 0, false
+}
@@ -0,0 +1,78 @@
+package lexer_test
+
+import (
+	"testing"
+	"unicode"
+
+	"github.com/PieterD/lexer"
+)
+
+const (
+	tokenLess lexer.TokenType = 1 + iota
+	tokenLessEq
+	tokenShift
+	tokenShiftEq
+	tokenIf
+	tokenIn
+	tokenInt
+	tokenIdent
+)
+
+var keywords = lexer.NewKeywords(map[string]lexer.TokenType{
+	"<":   tokenLess,
+	"<=":  tokenLessEq,
+	"<<":  tokenShift,
+	"<<=": tokenShiftEq,
+	"if":  tokenIf,
+	"in":  tokenIn,
+	"int": tokenInt,
+}).WordBoundary(func(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+})
+
+func keywordState(l *lexer.LexInner) lexer.StateFn {
+	l.Whitespace("")
+	l.Ignore()
+	if l.Eof() {
+		return l.EmitEof()
+	}
+	if typ, ok := l.Keyword(keywords); ok {
+		l.Emit(typ)
+		return keywordState
+	}
+	if l.Run(unicode.IsLetter) > 0 {
+		l.Emit(tokenIdent)
+		return keywordState
+	}
+	return l.Errorf("Unexpected %q", l.Next())
+}
+
+func TestKeywords(t *testing.T) {
+	tokens := lexAll(lexer.New("test", "<<=<<<=< if iffy in int inx in<x", keywordState))
+	expected := []struct {
+		typ lexer.TokenType
+		val string
+	}{
+		{tokenShiftEq, "<<="},
+		{tokenShift, "<<"},
+		{tokenLessEq, "<="},
+		{tokenLess, "<"},
+		{tokenIf, "if"},
+		{tokenIdent, "iffy"},
+		{tokenIn, "in"},
+		{tokenInt, "int"},
+		{tokenIdent, "inx"},
+		{tokenIn, "in"},
+		{tokenLess, "<"},
+		{tokenIdent, "x"},
+		{lexer.TokenEOF, "EOF"},
+	}
+	if len(tokens) != len(expected) {
+		t.Fatalf("Expected %d tokens, got %#v", len(expected), tokens)
+	}
+	for i, exp := range expected {
+		if tokens[i].Typ != exp.typ || tokens[i].Val != exp.val {
+			t.Fatalf("Token %d: expected %d %q, got %#v", i, exp.typ, exp.val, tokens[i])
+		}
+	}
+}
@@ -3,12 +3,18 @@ package lexer
 import (
 	"errors"
 	"fmt"
+	"io"
 	"strings"
 	"unicode"
 	"unicode/utf8"
 )
 
 var errTooManyEmits = errors.New("Too many emits in a single stat function")
+var errStopped = errors.New("Lexer was closed")
+var errReadFailed = errors.New("Read failed")
+
+// The minimum number of bytes read at once by a Lexer created with NewReader.
+const readSize = 4096
 
 // This is returned by next when there are no more characters to read.
 const Eof rune = -1
@@ -18,27 +24,109 @@ const Err rune = utf8.RuneError
 
 // LexInner is the inner type which is used within StateFn to do the actual lexing.
 type LexInner struct {
-	tokens chan Token
-	state  StateFn
-	name   string
-	input  string
-	mark   Mark
-	prev   Mark
-	async  bool
+	source
+	tokens   chan Token
+	state    StateFn
+	fset     *FileSet
+	resolver Resolver
+	endings  LineEndings
+	tabWidth int
+	includes []include
+	states   []StateFn
+	indents  []int
+	heredocs []heredoc
+	stop     chan struct{}
+	done     bool
+	mark     Mark
+	prev     Mark
+	async    bool
+}
+
+// A source of input, with its name and everything needed to read it.
+type source struct {
+	name     string
+	file     *File
+	input    string
+	data     []byte
+	borrowed bool
+	base     int
+	reader   io.Reader
+	chunk    []byte
+	rerr     error
+	closer   func() error
+	released bool
+	tr       *transcoding
+}
+
+// Return the offset in the original input for the given position.
+func (src *source) offset(pos int) int {
+	if src.tr == nil {
+		return pos
+	}
+	return src.tr.original(pos)
+}
+
+// Release the input, if it needs releasing.
+func (src *source) release() error {
+	if src.closer == nil {
+		return nil
+	}
+	closer := src.closer
+	src.closer = nil
+	// The input may refer to the memory that is released.
+	src.input, src.data, src.released = "", nil, true
+	return closer()
 }
 
 // The Mark type (used by Mark and Unmark) can be used to save
 // the current state of the lexer, and restore it later.
 type Mark struct {
-	pos     int
-	line    int
-	start   int
+	location
+	start   location
+	back    location
 	width   int
 	replace *Replacer
 }
 
+// A Mark at the very start of the input.
+func newMark() Mark {
+	loc := location{line: 1, col: 1, vcol: 1}
+	return Mark{location: loc, start: loc, back: loc}
+}
+
+// Return the line number of the Mark, starting at 1.
+func (mark Mark) Line() int {
+	return mark.line
+}
+
+// Return the column of the Mark in runes, starting at 1.
+func (mark Mark) Column() int {
+	return mark.col
+}
+
+// Return the visual column of the Mark, starting at 1.
+// This is the column as shown in an editor, with tabs expanded
+// to the tab width of the Lexer.
+func (mark Mark) VColumn() int {
+	return mark.vcol
+}
+
+// Return the byte offset of the Mark, starting at 0.
+func (mark Mark) Offset() int {
+	return mark.pos
+}
+
 func (mark Mark) rpos() int {
-	return mark.pos - mark.start
+	return mark.pos - mark.start.pos
+}
+
+// A location within the input.
+type location struct {
+	pos  int
+	line int
+	col  int
+	vcol int
+	cr   bool
 }
 
 type Replacer struct {
@@ -49,17 +137,93 @@ type Replacer struct {
 // StateFn is a function that takes a LexInner and returns a StateFn.
 type StateFn func(*LexInner) StateFn
 
+// Scan is the result of the scanning helpers of LexInner, such as Number.
+type Scan int
+
+const (
+	// The input does not start with what is being scanned.
+	// Nothing was accepted.
+	ScanNone Scan = iota
+	// It was successfully scanned and accepted.
+	ScanOK
+	// It was malformed. An error has been emitted using Errorf,
+	// so the state function should return nil.
+	ScanError
+)
+
 // Return the length of the token gathered so far.
 func (l *LexInner) Len() int {
-	return l.mark.pos - l.mark.start
+	return l.mark.pos - l.mark.start.pos
 }
 
 // Get the string of the token gathered so far.
 func (l *LexInner) Get() string {
-	str := l.input[l.mark.start:l.mark.pos]
+	str := l.input[l.mark.start.pos-l.base : l.mark.pos-l.base]
 	return str
 }
 
+// Get the token gathered so far as bytes.
+// For a Lexer created with NewBytes, this is a slice of the original input
+// which must not be modified. Otherwise, it is a copy.
+func (l *LexInner) GetBytes() []byte {
+	if l.data != nil {
+		return l.data[l.mark.start.pos:l.mark.pos:l.mark.pos]
+	}
+	return []byte(l.Get())
+}
+
+// The buffered input from the current position onward.
+func (l *LexInner) rest() string {
+	return l.input[l.mark.pos-l.base:]
+}
+
+// Make sure at least n bytes are buffered from the current position onward,
+// reading more from the reader if necessary.
+// Returns false if the input ends before that.
+// If reading failed and everything before the failure has been consumed,
+// lexing stops: see step.
+func (l *LexInner) fill(n int) bool {
+	for l.mark.pos+n > l.base+len(l.input) {
+		if l.reader == nil {
+			if l.rerr != nil && len(l.rest()) == 0 {
+				panic(errReadFailed)
+			}
+			return false
+		}
+		l.read()
+	}
+	return true
+}
+
+// Read another chunk from the reader.
+// Everything before the start of the current token is dropped from the buffer.
+func (l *LexInner) read() {
+	keep := l.input[l.mark.start.pos-l.base:]
+	size := readSize
+	if len(keep) > size {
+		size = len(keep)
+	}
+	if len(l.chunk) < size {
+		l.chunk = make([]byte, size)
+	}
+	n, err := l.reader.Read(l.chunk[:size])
+	if err != nil {
+		if err != io.EOF {
+			l.rerr = err
+		}
+		l.reader = nil
+	}
+	if n == 0 {
+		return
+	}
+	var buf strings.Builder
+	buf.Grow(len(keep) + n)
+	buf.WriteString(keep)
+	buf.Write(l.chunk[:n])
+	l.base = l.mark.start.pos
+	l.input = buf.String()
+}
+
 // Get the last character accepted into the token.
 func (l *LexInner) Last() rune {
 	if l.Len() == 0 {
@@ -75,6 +239,8 @@ func (l *LexInner) Mark() Mark {
 }
 
 // Recover the state of the lexer.
+// When reading from an io.Reader, only Marks made since the start
+// of the current token are guaranteed to be recoverable.
 func (l *LexInner) Unmark(mark Mark) {
 	l.mark = mark
 }
@@ -82,11 +248,12 @@ func (l *LexInner) Unmark(mark Mark) {
 // Replace the text from the start Mark to the current position with the given string.
 // With may be a different length than the string being replaced, but this change
 // will not be reflected by functions like Len and Get.
+// If with is empty, the text is removed.
 // Call ReplaceGet to get the token including its replaces. This is how it will be sent by Emit.
 // The replace is part of the current Mark, so Unmarking to before a replace was done will
 // remove the replace.
 func (l *LexInner) Replace(start Mark, with string) {
-	if with != "" {
+	if with != "" || start.pos != l.mark.pos {
 		l.mark.replace = &Replacer{start, l.mark, with}
 	}
 }
@@ -108,10 +275,33 @@ func replaceGet(cur string, replace *Replacer) string {
 }
 
 // Emit a token with the given type and string.
+// The token spans from the start of the token gathered so far
+// to the current position.
 func (l *LexInner) EmitString(typ TokenType, str string) {
-	tok := Token{typ, str, l.name, l.mark.line}
+	l.emit(typ, str, l.mark.start, l.mark.location)
+}
+
+func (l *LexInner) emit(typ TokenType, str string, from, to location) {
+	if l.borrowed {
+		str = strings.Clone(str)
+	}
+	tok := Token{
+		Typ:     typ,
+		Val:     str,
+		File:    l.name,
+		Line:    from.line,
+		Column:  from.col,
+		VColumn: from.vcol,
+		Offset:  l.offset(from.pos),
+		Pos:     l.file.Pos(l.offset(from.pos)),
+		End:     l.file.Pos(l.offset(to.pos)),
+	}
 	if l.async {
-		l.tokens <- tok
+		select {
+		case l.tokens <- tok:
+		case <-l.stop:
+			panic(errStopped)
+		}
 	} else {
 		select {
 		case l.tokens <- tok:
@@ -123,52 +313,98 @@ func (l *LexInner) EmitString(typ TokenType, str string) {
 
 // Emit the gathered token, given its type.
 // Emits the result of ReplaceGet, then calls Ignore.
+// With NormalizeLineEndings, line endings in the token are replaced by "\n".
 func (l *LexInner) Emit(typ TokenType) {
-	l.EmitString(typ, l.ReplaceGet())
+	str := l.ReplaceGet()
+	if l.endings == NormalizeLineEndings {
+		str = normalizeLineEndings(str)
+	}
+	l.EmitString(typ, str)
 	l.Ignore()
 }
 
-// Emit a token of type TokenEOF.
+// Emit a token of type TokenEOF at the current position.
+// If states pushed with PushState have not been popped, an error is
+// emitted instead.
 // Returns nil.
 func (l *LexInner) EmitEof() StateFn {
-	l.EmitString(TokenEOF, "EOF")
+	if len(l.states) > 0 {
+		return l.Errorf("Unexpected end of input with %d unpopped states", len(l.states))
+	}
+	l.emit(TokenEOF, "EOF", l.mark.location, l.mark.location)
 	return nil
 }
 
-// Emit an Error token.
+// Emit an Error token at the current position.
 // Like EmitEof, Errorf returns nil.
 func (l *LexInner) Errorf(format string, args ...interface{}) StateFn {
-	l.EmitString(TokenError, fmt.Sprintf(format, args...))
+	l.emit(TokenError, fmt.Sprintf(format, args...), l.mark.location, l.mark.location)
 	return nil
 }
 
-// Emit a Warning token.
+// Emit a Warning token at the current position.
 func (l *LexInner) Warningf(format string, args ...interface{}) {
-	l.EmitString(TokenWarning, fmt.Sprintf(format, args...))
+	l.emit(TokenWarning, fmt.Sprintf(format, args...), l.mark.location, l.mark.location)
+}
+
+// Run the current state function, and return the next one.
+// If reading the input failed, lexing stops once the state reaches the point
+// of failure, rather than treating it as the end of the input: nil is
+// returned, and finish reports the error.
+func (l *LexInner) step() (next StateFn) {
+	defer func() {
+		if err := recover(); err != nil {
+			if err != errReadFailed {
+				panic(err)
+			}
+			next = nil
+		}
+	}()
+	l.popEnded()
+	return l.state(l)
+}
+
+// Called once the last state function has returned nil.
+func (l *LexInner) finish() {
+	if l.rerr != nil {
+		l.Errorf("Read error: %v", l.rerr)
+	}
+	l.release()
+}
+
+// Release all inputs, including any that are being included.
+func (l *LexInner) release() error {
+	err := l.source.release()
+	for i := range l.includes {
+		if ierr := l.includes[i].source.release(); err == nil {
+			err = ierr
+		}
+	}
+	return err
 }
 
 // Return true if the lexer has reached the end of the file.
+// At the end of an included input, it returns true until the current token
+// has been emitted or ignored, after which lexing resumes in the including input.
 func (l *LexInner) Eof() bool {
-	if l.mark.pos >= len(l.input) {
-		return true
-	}
-	return false
+	return !l.fill(1)
 }
 
 // Read a single character.
 // If there are no more characters, it will return Eof.
 // If a non-utf8 character is read, it will return Err.
 func (l *LexInner) Next() (char rune) {
-	if l.Eof() {
+	eof := l.Eof()
+	l.mark.back = l.mark.location
+	if eof {
 		l.mark.width = 0
 		char = Eof
 		return Eof
 	}
-	char, l.mark.width = utf8.DecodeRuneInString(l.input[l.mark.pos:])
+	l.fill(utf8.UTFMax)
+	char, l.mark.width = utf8.DecodeRuneInString(l.rest())
 	l.mark.pos += l.mark.width
-	if char == '\n' {
-		l.mark.line++
-	}
+	l.count(char)
 	return char
 }
 
@@ -186,13 +422,11 @@ func (l *LexInner) Skip(n int) int {
 }
 
 // Undo the last Next.
-// This is probably won't work after calling any other lexer functions.
+// This also undoes the last String, Find or Bytes, but probably
+// won't work after calling any other lexer functions.
 // If you need to undo more, use Mark and Unmark.
 func (l *LexInner) Back() {
-	if l.Last() == '\n' {
-		l.mark.line--
-	}
-	l.mark.pos -= l.mark.width
+	l.mark.location = l.mark.back
 	l.mark.width = 0
 }
 
@@ -203,17 +437,70 @@ func (l *LexInner) Peek() rune {
 	return char
 }
 
+// Spy on the nth upcoming rune, so that PeekN(1) is the same rune as Peek.
+// Returns Eof if the input ends before it, and Err for invalid UTF-8.
+// Unlike Peek, it does not affect Back, and it does not look past the end
+// of an included file.
+func (l *LexInner) PeekN(n int) rune {
+	off := 0
+	for i := 1; i <= n; i++ {
+		l.fill(off + utf8.UTFMax)
+		rest := l.rest()[off:]
+		if len(rest) == 0 {
+			break
+		}
+		char, size := utf8.DecodeRuneInString(rest)
+		if i == n {
+			return char
+		}
+		off += size
+	}
+	return Eof
+}
+
+// Spy on the next n upcoming runes, or fewer if the input ends before that.
+// Invalid UTF-8 is returned as it is, each invalid byte counting as one rune.
+// Like PeekN, it does not affect Back or look past the end of an included file.
+func (l *LexInner) PeekString(n int) string {
+	off := 0
+	for i := 0; i < n; i++ {
+		l.fill(off + utf8.UTFMax)
+		rest := l.rest()[off:]
+		if len(rest) == 0 {
+			break
+		}
+		_, size := utf8.DecodeRuneInString(rest)
+		off += size
+	}
+	return l.rest()[:off]
+}
+
+// Return true if the upcoming input starts with the given string,
+// without accepting it.
+// Like PeekN, it does not affect Back or look past the end of an included file.
+func (l *LexInner) HasPrefix(prefix string) bool {
+	return l.fill(len(prefix)) && strings.HasPrefix(l.rest(), prefix)
+}
+
 // Ignore everything gathered about the token so far.
 // Also removes any Replaces.
 func (l *LexInner) Ignore() {
-	l.mark.start = l.mark.pos
+	l.ignore()
+	l.popEnded()
+}
+
+// Like Ignore, but stay in an included input whose end has been reached.
+func (l *LexInner) ignore() {
+	l.mark.start = l.mark.location
+	l.mark.back = l.mark.location
 	l.mark.width = 0
 	l.mark.replace = nil
 }
 
 // Retry everything since starting this token.
 func (l *LexInner) Retry() {
-	l.mark.pos = l.mark.start
+	l.mark.location = l.mark.start
+	l.mark.back = l.mark.location
 	l.mark.width = 0
 }
 
@@ -221,10 +508,8 @@ func (l *LexInner) Retry() {
 // Only if the entire string is successfully accepted does it return true.
 // If only a part of the string was matched, none of it is.
 func (l *LexInner) String(valid string) bool {
-	if strings.HasPrefix(l.input[l.mark.pos:], valid) {
-		l.mark.line += strings.Count(valid, "\n")
-		l.mark.pos += len(valid)
-		l.mark.width = len(valid)
+	if l.fill(len(valid)) && strings.HasPrefix(l.rest(), valid) {
+		l.accept(len(valid))
 		return true
 	}
 	return false
@@ -233,14 +518,31 @@ func (l *LexInner) String(valid string) bool {
 // Accepts things until the first occurence of the given string.
 // The string itself is not accepted.
 func (l *LexInner) Find(valid string) bool {
-	idx := strings.Index(l.input[l.mark.pos:], valid)
-	if idx >= 0 {
-		l.mark.line += strings.Count(l.input[l.mark.pos:l.mark.pos+idx], "\n")
-		l.mark.pos += idx
-		l.mark.width = idx
-		return true
+	from := 0
+	for {
+		rest := l.rest()
+		idx := strings.Index(rest[from:], valid)
+		if idx >= 0 {
+			l.accept(idx + from)
+			return true
+		}
+		if !l.fill(len(rest) + 1) {
+			return false
+		}
+		// Only search the newly read part, plus enough overlap for a match
+		// straddling the old end of the buffer.
+		from = len(rest) - len(valid) + 1
+		if from < 0 {
+			from = 0
+		}
 	}
-	return false
+}
+
+// Accept the given number of upcoming bytes, which must be buffered.
+func (l *LexInner) accept(number int) {
+	l.mark.back = l.mark.location
+	l.advance(l.rest()[:number])
+	l.mark.width = number
 }
 
 // Accept a single character and return true if f returns true.
@@ -313,10 +615,9 @@ func (l *LexInner) ExceptRun(valid string) (acceptnum int) {
 
 // Consume the given number of bytes. Returns true if successful, false if there are not enough bytes.
 func (l *LexInner) Bytes(number int) bool {
-	if l.mark.pos+number > len(l.input) {
+	if !l.fill(number) {
 		return false
 	}
-	l.mark.width = number
-	l.mark.pos += number
+	l.accept(number)
 	return true
 }
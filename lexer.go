@@ -1,5 +1,10 @@
 package lexer
 
+import (
+	"io"
+	"unsafe"
+)
+
 // The maximum number of emits in a single state function when using Token.
 // If this number has been reached, Token returns a StateError.
 // If you wish to emit more than this, use the Go method to read tokens
@@ -16,24 +21,135 @@ type Iterator struct {
 
 // Lexer is the external type which emits tokens.
 type Lexer struct {
-	lexer *LexInner
-	going bool
+	lexer    *LexInner
+	going    bool
+	streamed bool
 }
 
 // Create a new lexer.
 func New(name string, input string, start_state StateFn) *Lexer {
+	ln := newLexer(name, start_state)
+	ln.lexer.input = input
+	ln.register()
+	return ln
+}
+
+// Create a new lexer which lexes the given bytes in place, without copying them.
+// The input must not be modified until lexing is done.
+// The values of emitted tokens are copied, so they remain valid afterwards.
+func NewBytes(name string, input []byte, start_state StateFn) *Lexer {
+	ln := newLexer(name, start_state)
+	l := ln.lexer
+	l.input = unsafe.String(unsafe.SliceData(input), len(input))
+	l.data = input
+	l.borrowed = true
+	ln.register()
+	return ln
+}
+
+// Create a new lexer which reads its input from r as it goes.
+// Only the input since the start of the current token is kept in memory,
+// so arbitrarily large inputs can be lexed.
+// If reading fails, lexing stops once everything read before the failure
+// has been consumed: the token being gathered is dropped, and the error is
+// emitted as a TokenError instead of reaching the end of the input.
+func NewReader(name string, r io.Reader, start_state StateFn) *Lexer {
+	ln := newLexer(name, start_state)
+	ln.lexer.reader = r
+	ln.streamed = true
+	ln.register()
+	return ln
+}
+
+func newLexer(name string, start_state StateFn) *Lexer {
 	ln := new(Lexer)
 	ln.lexer = new(LexInner)
 	l := ln.lexer
+	l.fset = NewFileSet()
+	l.resolver = FileResolver
+	l.tabWidth = 8
 	l.tokens = make(chan Token, MaxEmitsInFunction)
+	l.stop = make(chan struct{})
 	l.state = start_state
 	l.name = name
-	l.input = input
-	l.mark.line = 1
-	l.prev.line = 1
+	l.mark = newMark()
+	l.prev = l.mark
 	return ln
 }
 
+// Add the input to the FileSet.
+func (ln *Lexer) register() {
+	l := ln.lexer
+	l.file = l.fset.AddFile(l.name)
+	if l.reader == nil && !l.borrowed {
+		l.file.setContent(l.input, l.tr)
+	}
+}
+
+// Return the FileSet the input of the Lexer is registered in.
+// The Pos and End of every Token can be resolved through it.
+// Unless SetFileSet is called, every Lexer has its own FileSet.
+func (ln *Lexer) FileSet() *FileSet {
+	return ln.lexer.fset
+}
+
+// Register the input of the Lexer in the given FileSet instead,
+// so that several Lexers can share one.
+// This must be called before Go or Iterate.
+func (ln *Lexer) SetFileSet(fset *FileSet) *Lexer {
+	ln.lexer.fset = fset
+	ln.register()
+	return ln
+}
+
+// Set which characters end a line. By default, only "\n" does.
+// This must be called before Go or Iterate.
+func (ln *Lexer) SetLineEndings(endings LineEndings) *Lexer {
+	ln.lexer.endings = endings
+	return ln
+}
+
+// Set the number of columns between tab stops, used for visual columns.
+// The default is 8.
+// This must be called before Go or Iterate.
+func (ln *Lexer) SetTabWidth(width int) *Lexer {
+	if width > 0 {
+		ln.lexer.tabWidth = width
+	}
+	return ln
+}
+
+// Set the Resolver used by LexInner.IncludeFile.
+// By default, this is FileResolver.
+func (ln *Lexer) SetResolver(resolver Resolver) *Lexer {
+	ln.lexer.resolver = resolver
+	return ln
+}
+
+// Stop lexing, and release any resources held by the Lexer,
+// such as the memory mapping made by NewFile.
+// This happens automatically once the last state function returns,
+// so Close is only needed when you stop reading tokens before that.
+// When using Go, lexing stops (and resources are released) the next time
+// a token is emitted, and Close always returns nil.
+func (ln *Lexer) Close() error {
+	l := ln.lexer
+	if l.async {
+		select {
+		case <-l.stop:
+		default:
+			close(l.stop)
+		}
+		return nil
+	}
+	l.state = nil
+	if !l.done {
+		l.done = true
+		close(l.tokens)
+	}
+	return l.release()
+}
+
 // Spawn a goroutine which keeps sending tokens on the returned channel,
 // until TokenEmpty would be encountered.
 // If Go or Iterate has already been called, it will return nil.
@@ -46,9 +162,16 @@ func (ln *Lexer) Go() Channel {
 	l.async = true
 	go func() {
 		defer close(l.tokens)
+		defer func() {
+			if err := recover(); err != nil && err != errStopped {
+				panic(err)
+			}
+		}()
+		defer l.release()
 		for {
-			l.state = l.state(l)
+			l.state = l.step()
 			if l.state == nil {
+				l.finish()
 				return
 			}
 		}
@@ -77,7 +200,17 @@ func (it Iterator) Token() (token Token) {
 	defer func() {
 		err := recover()
 		if err == errTooManyEmits {
-			token = Token{TokenError, errTooManyEmits.Error(), l.name, l.mark.line}
+			token = Token{
+				Typ:     TokenError,
+				Val:     errTooManyEmits.Error(),
+				File:    l.name,
+				Line:    l.mark.line,
+				Column:  l.mark.col,
+				VColumn: l.mark.vcol,
+				Offset:  l.offset(l.mark.pos),
+				Pos:     l.file.Pos(l.offset(l.mark.pos)),
+				End:     l.file.Pos(l.offset(l.mark.pos)),
+			}
 		}
 	}()
 
@@ -86,12 +219,14 @@ func (it Iterator) Token() (token Token) {
 		select {
 		case token, ok = <-l.tokens:
 			if !ok {
-				return Token{TokenEmpty, "", "", 0}
+				return Token{}
 			}
 			return token
 		default:
-			l.state = l.state(l)
+			l.state = l.step()
 			if l.state == nil {
+				l.finish()
+				l.done = true
 				close(l.tokens)
 			}
 		}
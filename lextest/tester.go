@@ -7,9 +7,10 @@ import (
 )
 
 type Tester struct {
-	it *lexer.Iterator
-	t  *testing.T
-	n  int
+	it  *lexer.Iterator
+	t   *testing.T
+	n   int
+	tok lexer.Token
 }
 
 // Testing lexers involves some boiler plate.
@@ -17,7 +18,7 @@ type Tester struct {
 // test your lexer for correctness.
 func NewTester(t *testing.T, f lexer.StateFn, text string) *Tester {
 	it := lexer.New("testing", text, f).Iterate()
-	return &Tester{it, t, 0}
+	return &Tester{it: it, t: t}
 }
 
 // Succeeds if the next token has the given type, value and line.
@@ -25,6 +26,7 @@ func NewTester(t *testing.T, f lexer.StateFn, text string) *Tester {
 func (lt *Tester) Expect(typ lexer.TokenType, val string, line int) *Tester {
 	lt.n++
 	tok := lt.it.Token()
+	lt.tok = tok
 	if tok.Typ != typ || tok.Val != val || tok.Line != line {
 		lt.t.Logf("Token %d:      got [typ:%2d line:%3d val:'%s']", lt.n, tok.Typ, tok.Line, tok.Val)
 		lt.t.Logf("Token %d: expected [typ:%2d line:%3d val:'%s']", lt.n, typ, line, val)
@@ -47,3 +49,13 @@ func (lt *Tester) Error(val string, line int) *Tester {
 func (lt *Tester) End() *Tester {
 	return lt.Expect(lexer.TokenEmpty, "", 0)
 }
+
+// Succeeds if the last token starts at the given column.
+func (lt *Tester) Column(col int) *Tester {
+	if lt.tok.Column != col {
+		lt.t.Logf("Token %d:      got [line:%3d col:%3d val:'%s']", lt.n, lt.tok.Line, lt.tok.Column, lt.tok.Val)
+		lt.t.Logf("Token %d: expected [line:%3d col:%3d]", lt.n, lt.tok.Line, col)
+		lt.t.Fatalf("Token %d Column failed", lt.n)
+	}
+	return lt
+}
@@ -0,0 +1,173 @@
+package lexer
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// A LineIndex converts between byte offsets, lines and columns in a text,
+// such as the input of a Lexer.
+// Columns are counted either in runes, like the Column of a Token, or in UTF-16
+// code units, like the character of a position in the Language Server Protocol.
+// All lines and columns start at 1; LSP positions start at 0, so subtract one
+// from both.
+// Offsets, lines and columns that are out of range are clamped to the nearest
+// position that exists.
+type LineIndex struct {
+	text  string
+	lines []int
+	// If the text was decoded, tr maps offsets in the original input to the text.
+	tr *transcoding
+}
+
+// Create a LineIndex for the given text, using the given line endings.
+func NewLineIndex(text string, endings LineEndings) *LineIndex {
+	li := &LineIndex{text: text, lines: []int{0}}
+	for i := 0; i < len(text); i++ {
+		switch {
+		case text[i] == '\n':
+			li.lines = append(li.lines, i+1)
+		case text[i] == '\r' && endings != LineFeed:
+			if i+1 < len(text) && text[i+1] == '\n' {
+				i++
+			}
+			li.lines = append(li.lines, i+1)
+		}
+	}
+	return li
+}
+
+// Return the number of lines.
+func (li *LineIndex) Lines() int {
+	return len(li.lines)
+}
+
+// Return the text of the given line, without its line ending.
+func (li *LineIndex) line(line int) (start int, text string) {
+	if line < 1 {
+		line = 1
+	}
+	if line > len(li.lines) {
+		line = len(li.lines)
+	}
+	start = li.lines[line-1]
+	end := len(li.text)
+	if line < len(li.lines) {
+		end = li.lines[line]
+	}
+	text = li.text[start:end]
+	for len(text) > 0 && (text[len(text)-1] == '\n' || text[len(text)-1] == '\r') {
+		text = text[:len(text)-1]
+	}
+	return start, text
+}
+
+// Return the line containing the given offset, and the offset of its start.
+func (li *LineIndex) find(offset int) (line int, start int) {
+	if offset < 0 {
+		offset = 0
+	}
+	line = sort.Search(len(li.lines), func(i int) bool {
+		return li.lines[i] > offset
+	})
+	return line, li.lines[line-1]
+}
+
+// Return the line and rune column of the given offset.
+func (li *LineIndex) Position(offset int) (line, col int) {
+	if li.tr != nil {
+		offset = li.tr.decoded(offset)
+	}
+	line, start := li.find(offset)
+	_, text := li.line(line)
+	if offset-start < len(text) {
+		text = text[:offset-start]
+	}
+	return line, utf8.RuneCountInString(text) + 1
+}
+
+// Return the offset of the given line and rune column.
+func (li *LineIndex) Offset(line, col int) int {
+	start, text := li.line(line)
+	i := 0
+	for n := 1; n < col && i < len(text); n++ {
+		_, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+	}
+	if li.tr != nil {
+		return li.tr.original(start + i)
+	}
+	return start + i
+}
+
+// Return the line and UTF-16 column of the given offset.
+func (li *LineIndex) UTF16Position(offset int) (line, col16 int) {
+	line, col := li.Position(offset)
+	return line, li.ToUTF16(line, col)
+}
+
+// Return the offset of the given line and UTF-16 column.
+func (li *LineIndex) UTF16Offset(line, col16 int) int {
+	return li.Offset(line, li.FromUTF16(line, col16))
+}
+
+// Convert a rune column on the given line to a UTF-16 column.
+func (li *LineIndex) ToUTF16(line, col int) int {
+	_, text := li.line(line)
+	col16 := 1
+	for _, char := range text {
+		if col <= 1 {
+			break
+		}
+		col--
+		col16 += utf16Width(char) / 2
+	}
+	return col16
+}
+
+// Convert a UTF-16 column on the given line to a rune column.
+// A column in the middle of a surrogate pair is rounded up.
+func (li *LineIndex) FromUTF16(line, col16 int) int {
+	_, text := li.line(line)
+	col := 1
+	for _, char := range text {
+		if col16 <= 1 {
+			break
+		}
+		col16 -= utf16Width(char) / 2
+		col++
+	}
+	return col
+}
+
+// Return a LineIndex for the input of the Lexer, using its line endings,
+// so that the Line, Column and Offset of its tokens can be converted.
+// Like those of tokens, offsets refer to the original input; for NewEncoded,
+// that is the input before it was decoded.
+// Input that is lexed in place, as by NewBytes and NewFile, is copied, so the
+// LineIndex remains valid after lexing is done.
+// Call it before Go, or after the channel returned by Go has been closed;
+// with Iterate, call it between calls to Token.
+// Returns nil for a Lexer created with NewReader, as its input is not kept,
+// and, once lexing is done or Close has been called, for one whose file
+// was memory-mapped by NewFile, as it is then no longer mapped.
+func (ln *Lexer) LineIndex() *LineIndex {
+	if ln.streamed {
+		return nil
+	}
+	src := ln.lexer.source
+	if len(ln.lexer.includes) > 0 {
+		src = ln.lexer.includes[0].source
+	}
+	if src.released {
+		return nil
+	}
+	input := src.input
+	if src.borrowed {
+		input = strings.Clone(input)
+	}
+	li := NewLineIndex(input, ln.lexer.endings)
+	li.tr = src.tr
+	return li
+}
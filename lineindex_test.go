@@ -0,0 +1,94 @@
+package lexer_test
+
+import (
+	"testing"
+
+	"github.com/PieterD/lexer"
+)
+
+func TestLineIndex(t *testing.T) {
+	text := "ab\r\nx😀y = \"z\";\rlast"
+	li := lexer.NewLineIndex(text, lexer.AnyLineEnding)
+	if li.Lines() != 3 {
+		t.Fatalf("Expected 3 lines, got %d", li.Lines())
+	}
+	tests := []struct {
+		offset, line, col, col16 int
+	}{
+		{0, 1, 1, 1},
+		{2, 1, 3, 3},
+		{4, 2, 1, 1},
+		{5, 2, 2, 2},
+		{9, 2, 3, 4},
+		{10, 2, 4, 5},
+		{18, 3, 1, 1},
+		{22, 3, 5, 5},
+	}
+	for _, test := range tests {
+		if line, col := li.Position(test.offset); line != test.line || col != test.col {
+			t.Fatalf("Position(%d): expected %d:%d, got %d:%d", test.offset, test.line, test.col, line, col)
+		}
+		if line, col16 := li.UTF16Position(test.offset); line != test.line || col16 != test.col16 {
+			t.Fatalf("UTF16Position(%d): expected %d:%d, got %d:%d", test.offset, test.line, test.col16, line, col16)
+		}
+		if offset := li.Offset(test.line, test.col); offset != test.offset {
+			t.Fatalf("Offset(%d, %d): expected %d, got %d", test.line, test.col, test.offset, offset)
+		}
+		if offset := li.UTF16Offset(test.line, test.col16); offset != test.offset {
+			t.Fatalf("UTF16Offset(%d, %d): expected %d, got %d", test.line, test.col16, test.offset, offset)
+		}
+	}
+	if col := li.FromUTF16(2, 3); col != 3 {
+		t.Fatalf("Expected the middle of a surrogate pair to round up to 3, got %d", col)
+	}
+	if offset := li.Offset(1, 10); offset != 2 {
+		t.Fatalf("Expected column past the end of line to clamp to 2, got %d", offset)
+	}
+	if offset := li.Offset(10, 1); offset != 18 {
+		t.Fatalf("Expected line past the end to clamp to 18, got %d", offset)
+	}
+}
+
+func TestLexerLineIndex(t *testing.T) {
+	ln := lexer.New("test", "foo = \"ä😀\";\nbar = 1;", symbolState)
+	li := ln.LineIndex()
+	tokens := lexAll(ln)
+	if semi := tokens[3]; li.ToUTF16(semi.Line, semi.Column) != 12 {
+		t.Fatalf("Expected UTF-16 column 12, got %d", li.ToUTF16(semi.Line, semi.Column))
+	}
+	if li.Offset(tokens[4].Line, tokens[4].Column) != tokens[4].Offset {
+		t.Fatalf("Offset mismatch for %#v", tokens[4])
+	}
+}
+
+func TestLexerLineIndexAfterFile(t *testing.T) {
+	ln, err := lexer.NewFile(writeFile(t, "foo = 500;\nbär = 1;"), symbolState)
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+	li := ln.LineIndex()
+	lexAll(ln)
+	if line, col := li.Position(13); line != 2 || col != 3 {
+		t.Fatalf("Expected 2:3, got %d:%d", line, col)
+	}
+	if li := ln.LineIndex(); li != nil {
+		t.Fatalf("Expected no LineIndex after the file was unmapped, got %#v", li)
+	}
+}
+
+func TestLexerLineIndexEncoded(t *testing.T) {
+	input := append([]byte("\xef\xbb\xbf"), "foo = 1;\nbär = 2;"...)
+	ln := lexer.NewEncoded("test", input, lexer.AutoEncoding, symbolState)
+	li := ln.LineIndex()
+	for _, token := range lexAll(ln) {
+		if token.Typ == lexer.TokenEOF {
+			continue
+		}
+		if line, col := li.Position(token.Offset); line != token.Line || col != token.Column {
+			t.Fatalf("Expected %d:%d for %#v, got %d:%d", token.Line, token.Column, token, line, col)
+		}
+		if offset := li.Offset(token.Line, token.Column); offset != token.Offset {
+			t.Fatalf("Expected offset %d for %#v, got %d", token.Offset, token, offset)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package lexer
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// LineEndings determines which characters end a line, for the purpose
+// of counting lines and columns.
+type LineEndings int
+
+const (
+	// Only "\n" ends a line. This is the default.
+	LineFeed LineEndings = iota
+	// "\n", "\r\n" and "\r" each end a line.
+	AnyLineEnding
+	// Like AnyLineEnding, but LexInner.Emit also replaces each line ending
+	// in the token by "\n".
+	NormalizeLineEndings
+)
+
+// Replace "\r\n" and "\r" by "\n".
+func normalizeLineEndings(str string) string {
+	if strings.IndexByte(str, '\r') < 0 {
+		return str
+	}
+	str = strings.ReplaceAll(str, "\r\n", "\n")
+	return strings.ReplaceAll(str, "\r", "\n")
+}
+
+// Return true if char ends a line, given the line endings.
+func (l *LexInner) endsLine(char rune) bool {
+	return char == '\n' || char == '\r' && l.endings != LineFeed
+}
+
+// Update the line and column for a character that was just consumed.
+func (l *LexInner) count(char rune) {
+	switch {
+	case char == '\n' && l.mark.cr:
+		// The second half of "\r\n"; the line now starts after it.
+		l.mark.cr = false
+		l.file.setLine(l.mark.line, l.offset(l.mark.pos))
+	case char == '\n' || char == '\r' && l.endings != LineFeed:
+		l.mark.line++
+		l.mark.col = 1
+		l.mark.vcol = 1
+		l.mark.cr = char == '\r'
+		l.file.setLine(l.mark.line, l.offset(l.mark.pos))
+	case char == '\t':
+		l.mark.col++
+		l.mark.vcol = l.tabStop(l.mark.vcol)
+		l.mark.cr = false
+	default:
+		l.mark.col++
+		l.mark.vcol++
+		l.mark.cr = false
+	}
+}
+
+// Return the visual column of the tab stop following the given visual column.
+func (l *LexInner) tabStop(vcol int) int {
+	return (vcol-1)/l.tabWidth*l.tabWidth + l.tabWidth + 1
+}
+
+// Update the columns for a string that was just consumed,
+// which does not contain any line endings.
+func (l *LexInner) columns(str string) {
+	n := utf8.RuneCountInString(str)
+	l.mark.col += n
+	l.mark.cr = false
+	if strings.IndexByte(str, '\t') < 0 {
+		l.mark.vcol += n
+		return
+	}
+	for _, char := range str {
+		if char == '\t' {
+			l.mark.vcol = l.tabStop(l.mark.vcol)
+		} else {
+			l.mark.vcol++
+		}
+	}
+}
+
+// Move the current position past the given string,
+// which must be the upcoming input.
+func (l *LexInner) advance(str string) {
+	breaks := "\n"
+	if l.endings != LineFeed {
+		breaks = "\r\n"
+	}
+	for {
+		idx := strings.IndexAny(str, breaks)
+		if idx < 0 {
+			break
+		}
+		if idx > 0 {
+			l.columns(str[:idx])
+		}
+		l.mark.pos += idx + 1
+		l.count(rune(str[idx]))
+		str = str[idx+1:]
+	}
+	if str != "" {
+		l.mark.pos += len(str)
+		l.columns(str)
+	}
+}
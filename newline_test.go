@@ -0,0 +1,92 @@
+package lexer
+
+import "testing"
+
+func TestLineEndings(t *testing.T) {
+	ln := New("test", "a\rb\r\nc\nd", nil).SetLineEndings(AnyLineEnding)
+	l := ln.lexer
+	check := func(line, col int) {
+		t.Helper()
+		if l.mark.line != line || l.mark.col != col {
+			t.Fatalf("Expected %d:%d, got %d:%d", line, col, l.mark.line, l.mark.col)
+		}
+	}
+	l.Next()
+	l.Next()
+	check(2, 1)
+	l.Back()
+	check(1, 2)
+	l.Next()
+	l.Next()
+	l.Next()
+	check(3, 1)
+	l.Next()
+	check(3, 1)
+	l.Back()
+	check(3, 1)
+	l.Back()
+	if l.Peek() != '\n' {
+		t.Fatalf("Expected '\\n'")
+	}
+	l.Retry()
+	if !l.String("a\rb\r") {
+		t.Fatalf("String failed")
+	}
+	check(3, 1)
+	if !l.Find("d") {
+		t.Fatalf("Find failed")
+	}
+	check(4, 1)
+	pos := ln.FileSet().Position(l.file.Pos(5))
+	if pos.Line != 3 || pos.Column != 1 {
+		t.Fatalf("Expected offset 5 at 3:1, got %s", pos)
+	}
+	l.Unmark(newMark())
+	if !l.Bytes(3) {
+		t.Fatalf("Bytes failed")
+	}
+	check(2, 2)
+}
+
+func TestLineFeedOnly(t *testing.T) {
+	l := New("test", "a\rb\r\nc", nil).lexer
+	l.ExceptRun("")
+	if l.mark.line != 2 || l.mark.col != 2 {
+		t.Fatalf("Expected 2:2, got %d:%d", l.mark.line, l.mark.col)
+	}
+}
+
+func TestNormalizeLineEndings(t *testing.T) {
+	state := func(l *LexInner) StateFn {
+		l.ExceptRun("")
+		l.Emit(1)
+		return l.EmitEof()
+	}
+	it := New("test", "a\rb\r\nc\n", state).SetLineEndings(NormalizeLineEndings).Iterate()
+	if token := it.Token(); token.Val != "a\nb\nc\n" {
+		t.Fatalf("Expected normalized value, got %q", token.Val)
+	}
+	if token := it.Token(); token.Line != 4 {
+		t.Fatalf("Expected EOF on line 4, got %d", token.Line)
+	}
+}
+
+func TestVisualColumns(t *testing.T) {
+	ln := New("test", "\ta\tbc\td\n\tx", nil).SetTabWidth(4)
+	l := ln.lexer
+	expected := []int{5, 6, 9, 10, 11, 13, 14, 1, 5}
+	for i, vcol := range expected {
+		l.Next()
+		if l.Mark().VColumn() != vcol {
+			t.Fatalf("Rune %d: expected visual column %d, got %d", i, vcol, l.Mark().VColumn())
+		}
+	}
+	l.Back()
+	if l.Mark().VColumn() != 1 {
+		t.Fatalf("Expected visual column 1 after Back, got %d", l.Mark().VColumn())
+	}
+	l.Retry()
+	if !l.Find("d") || l.Mark().VColumn() != 13 || l.Mark().Column() != 7 {
+		t.Fatalf("Expected 7 (13) after Find, got %d (%d)", l.Mark().Column(), l.Mark().VColumn())
+	}
+}
@@ -0,0 +1,244 @@
+package lexer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NumberKind is the kind of numeric literal recognized by LexInner.Number.
+type NumberKind int
+
+const (
+	NumberNone NumberKind = iota
+	NumberDecimal
+	NumberHex
+	NumberOctal
+	NumberBinary
+	NumberFloat
+)
+
+// Return the name of the kind, as used in error messages.
+func (kind NumberKind) String() string {
+	switch kind {
+	case NumberDecimal:
+		return "decimal"
+	case NumberHex:
+		return "hexadecimal"
+	case NumberOctal:
+		return "octal"
+	case NumberBinary:
+		return "binary"
+	case NumberFloat:
+		return "floating-point"
+	}
+	return "number"
+}
+
+// A NumberProfile determines which numeric literals LexInner.Number accepts.
+type NumberProfile struct {
+	// Allow a leading '-'.
+	Sign bool
+	// Allow 0x, 0o and 0b prefixes.
+	Hex, Octal, Binary bool
+	// A leading 0 makes an integer octal, as in C.
+	LegacyOctal bool
+	// Allow a leading 0 in decimal numbers other than 0 itself.
+	LeadingZeros bool
+	// Allow floating-point numbers with a fraction or exponent.
+	Float bool
+	// Allow floating-point numbers starting or ending with '.', like .5 and 5.
+	BareDot bool
+	// Allow hexadecimal floating-point numbers, like 0x1.8p3.
+	HexFloat bool
+	// Allow '_' between digits, and after a base prefix.
+	Separators bool
+	// Suffixes that may follow the number, matched case-insensitively.
+	Suffixes []string
+}
+
+var (
+	// Numeric literals as in Go.
+	GoNumbers = &NumberProfile{
+		Hex: true, Octal: true, Binary: true, LegacyOctal: true, LeadingZeros: true,
+		Float: true, BareDot: true, HexFloat: true, Separators: true,
+		Suffixes: []string{"i"},
+	}
+	// Numeric literals as in C.
+	CNumbers = &NumberProfile{
+		Hex: true, LegacyOctal: true, LeadingZeros: true,
+		Float: true, BareDot: true, HexFloat: true,
+		Suffixes: []string{"ull", "llu", "ul", "lu", "ll", "u", "l", "f"},
+	}
+	// Numbers as in JSON.
+	JSONNumbers = &NumberProfile{
+		Sign: true, Float: true,
+	}
+)
+
+var (
+	decimalDigits = Range('0', '9')
+	hexDigits     = decimalDigits.Union(Range('a', 'f'), Range('A', 'F'))
+	octalDigits   = Range('0', '7')
+	binaryDigits  = Chars("01")
+	numberTail    = Table(unicode.Letter).Union(decimalDigits, Chars("_"))
+)
+
+// Accept a run of digits, separated by '_' if allowed.
+// Returns the number of digits, and false if a '_' is not followed by a digit.
+func (l *LexInner) digits(set CharSet, separators bool) (int, bool) {
+	n := 0
+	for {
+		if l.AcceptSet(set) {
+			n++
+			continue
+		}
+		if separators && l.Accept("_") {
+			if !l.AcceptSet(set) {
+				return n, false
+			}
+			n++
+			continue
+		}
+		return n, true
+	}
+}
+
+// Accept a numeric literal as allowed by the profile, and return its kind.
+// If the input does not start with a number, ScanNone is returned.
+// If it is malformed, such as 0x or 1e+, an error describing it is emitted
+// and ScanError is returned.
+func (l *LexInner) Number(p *NumberProfile) (NumberKind, Scan) {
+	start := l.Mark()
+	signed := p.Sign && l.Accept("-")
+	digit := l.Mark()
+	if !l.AcceptSet(decimalDigits) && !(p.Float && p.BareDot && l.Accept(".") && l.AcceptSet(decimalDigits)) {
+		if signed {
+			l.Unmark(digit)
+			return NumberNone, l.errorf("Expected digit after '-'")
+		}
+		l.Unmark(start)
+		return NumberNone, ScanNone
+	}
+	l.Unmark(digit)
+	kind := NumberDecimal
+	if l.Accept("0") {
+		var set CharSet
+		switch {
+		case p.Hex && l.Accept("xX"):
+			kind, set = NumberHex, hexDigits
+		case p.Octal && l.Accept("oO"):
+			kind, set = NumberOctal, octalDigits
+		case p.Binary && l.Accept("bB"):
+			kind, set = NumberBinary, binaryDigits
+		}
+		if kind != NumberDecimal {
+			return l.prefixedNumber(p, kind, set)
+		}
+		l.Unmark(digit)
+	}
+	return l.decimalNumber(p)
+}
+
+// Scan the rest of a number with a 0x, 0o or 0b prefix.
+func (l *LexInner) prefixedNumber(p *NumberProfile, kind NumberKind, set CharSet) (NumberKind, Scan) {
+	n, ok := l.digits(set, p.Separators)
+	if !ok {
+		return kind, l.separatorError()
+	}
+	if kind == NumberHex && p.HexFloat && l.Accept(".") {
+		fraction, ok := l.digits(set, p.Separators)
+		if !ok {
+			return kind, l.separatorError()
+		}
+		n += fraction
+		kind = NumberFloat
+	}
+	if n == 0 {
+		return kind, l.errorf("No digits in %s literal", kind)
+	}
+	if p.HexFloat && (kind == NumberHex || kind == NumberFloat) && l.Accept("pP") {
+		kind = NumberFloat
+		if scan := l.exponent(p); scan != ScanOK {
+			return kind, scan
+		}
+	} else if kind == NumberFloat {
+		return kind, l.errorf("Hexadecimal floating-point literal has no 'p' exponent")
+	}
+	return kind, l.numberEnd(p, kind)
+}
+
+// Scan the rest of a decimal, octal or floating-point number.
+func (l *LexInner) decimalNumber(p *NumberProfile) (NumberKind, Scan) {
+	kind := NumberDecimal
+	first := l.Peek()
+	from := l.Len()
+	n, ok := l.digits(decimalDigits, p.Separators)
+	if !ok {
+		return kind, l.separatorError()
+	}
+	digits := l.Get()[from:]
+	if p.Float && l.Accept(".") {
+		kind = NumberFloat
+		fraction, ok := l.digits(decimalDigits, p.Separators)
+		if !ok {
+			return kind, l.separatorError()
+		}
+		if fraction == 0 && !p.BareDot {
+			return kind, l.errorf("Expected digit after '.'")
+		}
+	}
+	if p.Float && l.Accept("eE") {
+		kind = NumberFloat
+		if scan := l.exponent(p); scan != ScanOK {
+			return kind, scan
+		}
+	}
+	if first == '0' && n > 1 {
+		switch {
+		case kind == NumberDecimal && p.LegacyOctal:
+			kind = NumberOctal
+			if i := strings.IndexAny(digits, "89"); i >= 0 {
+				return kind, l.errorf("Invalid digit %q in octal literal", digits[i])
+			}
+		case !p.LeadingZeros:
+			return kind, l.errorf("Leading zeros are not allowed")
+		}
+	}
+	return kind, l.numberEnd(p, kind)
+}
+
+// Scan an exponent, after the 'e' or 'p'.
+func (l *LexInner) exponent(p *NumberProfile) Scan {
+	l.Accept("+-")
+	n, ok := l.digits(decimalDigits, p.Separators)
+	if !ok {
+		return l.separatorError()
+	}
+	if n == 0 {
+		return l.errorf("Exponent has no digits")
+	}
+	return ScanOK
+}
+
+// Accept a suffix, and make sure the number is not followed by more letters or digits.
+func (l *LexInner) numberEnd(p *NumberProfile, kind NumberKind) Scan {
+	for _, suffix := range p.Suffixes {
+		if l.StringFold(suffix) {
+			break
+		}
+	}
+	if char := l.Peek(); numberTail.Contains(char) {
+		return l.errorf("Invalid character %q in %s literal", char, kind)
+	}
+	return ScanOK
+}
+
+func (l *LexInner) separatorError() Scan {
+	return l.errorf("'_' must separate successive digits")
+}
+
+// Like Errorf, but returns ScanError.
+func (l *LexInner) errorf(format string, args ...interface{}) Scan {
+	l.Errorf(format, args...)
+	return ScanError
+}
@@ -0,0 +1,119 @@
+package lexer_test
+
+import (
+	"testing"
+
+	"github.com/PieterD/lexer"
+)
+
+func numberState(p *lexer.NumberProfile) lexer.StateFn {
+	return func(l *lexer.LexInner) lexer.StateFn {
+		kind, scan := l.Number(p)
+		switch scan {
+		case lexer.ScanNone:
+			return l.Errorf("Not a number")
+		case lexer.ScanError:
+			return nil
+		}
+		l.Emit(lexer.TokenType(kind))
+		return nil
+	}
+}
+
+func TestNumber(t *testing.T) {
+	tests := []struct {
+		profile *lexer.NumberProfile
+		input   string
+		kind    lexer.NumberKind
+		val     string
+	}{
+		{lexer.GoNumbers, "123", lexer.NumberDecimal, "123"},
+		{lexer.GoNumbers, "1_000_000+", lexer.NumberDecimal, "1_000_000"},
+		{lexer.GoNumbers, "0x_1F", lexer.NumberHex, "0x_1F"},
+		{lexer.GoNumbers, "0o17", lexer.NumberOctal, "0o17"},
+		{lexer.GoNumbers, "0755", lexer.NumberOctal, "0755"},
+		{lexer.GoNumbers, "0b1010)", lexer.NumberBinary, "0b1010"},
+		{lexer.GoNumbers, "0", lexer.NumberDecimal, "0"},
+		{lexer.GoNumbers, "0.5", lexer.NumberFloat, "0.5"},
+		{lexer.GoNumbers, "09.5", lexer.NumberFloat, "09.5"},
+		{lexer.GoNumbers, ".5e-3", lexer.NumberFloat, ".5e-3"},
+		{lexer.GoNumbers, "1.", lexer.NumberFloat, "1."},
+		{lexer.GoNumbers, "6.02E+23", lexer.NumberFloat, "6.02E+23"},
+		{lexer.GoNumbers, "0x1.8p3", lexer.NumberFloat, "0x1.8p3"},
+		{lexer.GoNumbers, "2i", lexer.NumberDecimal, "2i"},
+		{lexer.CNumbers, "42ULL;", lexer.NumberDecimal, "42ULL"},
+		{lexer.CNumbers, "1.5f", lexer.NumberFloat, "1.5f"},
+		{lexer.CNumbers, "0xFFu", lexer.NumberHex, "0xFFu"},
+		{lexer.JSONNumbers, "-0.25e10,", lexer.NumberFloat, "-0.25e10"},
+		{lexer.JSONNumbers, "0]", lexer.NumberDecimal, "0"},
+	}
+	for _, test := range tests {
+		token := lexer.New("test", test.input, numberState(test.profile)).Iterate().Token()
+		if token.Typ != lexer.TokenType(test.kind) || token.Val != test.val {
+			t.Fatalf("%q: expected %s %q, got %#v", test.input, test.kind, test.val, token)
+		}
+	}
+}
+
+func TestNumberErrors(t *testing.T) {
+	tests := []struct {
+		profile *lexer.NumberProfile
+		input   string
+		err     string
+		column  int
+	}{
+		{lexer.GoNumbers, "x", "Not a number", 1},
+		{lexer.GoNumbers, "0x", "No digits in hexadecimal literal", 3},
+		{lexer.GoNumbers, "0b", "No digits in binary literal", 3},
+		{lexer.GoNumbers, "0b102", "Invalid character '2' in binary literal", 5},
+		{lexer.GoNumbers, "1e+", "Exponent has no digits", 4},
+		{lexer.GoNumbers, "1__0", "'_' must separate successive digits", 3},
+		{lexer.GoNumbers, "10_", "'_' must separate successive digits", 4},
+		{lexer.GoNumbers, "0789", "Invalid digit '8' in octal literal", 5},
+		{lexer.GoNumbers, "0x1.8", "Hexadecimal floating-point literal has no 'p' exponent", 6},
+		{lexer.GoNumbers, "123abc", "Invalid character 'a' in decimal literal", 4},
+		{lexer.CNumbers, "1_000", "Invalid character '_' in decimal literal", 2},
+		{lexer.JSONNumbers, "01", "Leading zeros are not allowed", 3},
+		{lexer.JSONNumbers, "1.", "Expected digit after '.'", 3},
+		{lexer.JSONNumbers, ".5", "Not a number", 1},
+		{lexer.JSONNumbers, "-", "Expected digit after '-'", 2},
+		{lexer.JSONNumbers, "0x1", "Invalid character 'x' in decimal literal", 2},
+	}
+	for _, test := range tests {
+		token := lexer.New("test", test.input, numberState(test.profile)).Iterate().Token()
+		if token.Typ != lexer.TokenError || token.Val != test.err || token.Column != test.column {
+			t.Fatalf("%q: expected error %q at column %d, got %#v", test.input, test.err, test.column, token)
+		}
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	var state lexer.StateFn
+	state = func(l *lexer.LexInner) lexer.StateFn {
+		l.AcceptRun(" \n")
+		l.Ignore()
+		if l.Eof() {
+			return l.EmitEof()
+		}
+		kind, scan := l.Number(lexer.GoNumbers)
+		if scan != lexer.ScanOK {
+			return nil
+		}
+		l.Emit(lexer.TokenType(kind))
+		return state
+	}
+	tokens := []lexer.Token{
+		{Typ: lexer.TokenType(lexer.NumberDecimal), Val: "1_000", Line: 1, Column: 1},
+		{Typ: lexer.TokenType(lexer.NumberHex), Val: "0x1F", Line: 1, Column: 7},
+		{Typ: lexer.TokenType(lexer.NumberFloat), Val: "2.5e3", Line: 2, Column: 2},
+		{Typ: lexer.TokenType(lexer.NumberOctal), Val: "0755", Line: 2, Column: 8},
+		{Typ: lexer.TokenError, Val: "Invalid character '2' in binary literal", Line: 2, Column: 16},
+	}
+	it := lexer.New("test", "1_000 0x1F\n 2.5e3 0755 0b12", state).Iterate()
+	for i, exp := range tokens {
+		token := it.Token()
+		if token.Typ != exp.Typ || token.Val != exp.Val || token.Line != exp.Line || token.Column != exp.Column {
+			t.Fatalf("Token %d: expected %#v, got %#v", i, exp, token)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package lexer
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"unicode/utf8"
+)
+
+// The number of bits of a Pos used for the offset within its File.
+// The remaining bits identify the File within its FileSet.
+const offsetBits = 40
+
+// Pos is a compact representation of a position within a FileSet.
+// It can be turned into a Position using FileSet.Position.
+// The zero value is NoPos.
+type Pos int64
+
+// NoPos is the zero value for Pos; it is not part of any file.
+const NoPos Pos = 0
+
+// Return true if the Pos is not NoPos.
+func (p Pos) IsValid() bool {
+	return p != NoPos
+}
+
+// Position describes a position within a file.
+// Line and Column start at 1, Offset starts at 0.
+type Position struct {
+	Filename string
+	Offset   int
+	Line     int
+	Column   int
+}
+
+// Return true if the Position refers to a line.
+func (pos Position) IsValid() bool {
+	return pos.Line > 0
+}
+
+// Return the position as "file:line:column", leaving out anything that is unknown.
+func (pos Position) String() string {
+	s := pos.Filename
+	if pos.IsValid() {
+		if s != "" {
+			s += ":"
+		}
+		s += fmt.Sprintf("%d", pos.Line)
+		if pos.Column != 0 {
+			s += fmt.Sprintf(":%d", pos.Column)
+		}
+	}
+	if s == "" {
+		s = "-"
+	}
+	return s
+}
+
+// A FileSet keeps track of the files lexed by one or more Lexers,
+// so that a Pos can be turned back into a Position.
+// It is safe for concurrent use.
+type FileSet struct {
+	mutex sync.RWMutex
+	files []*File
+}
+
+// Create a new, empty FileSet.
+func NewFileSet() *FileSet {
+	return new(FileSet)
+}
+
+// Add a file with the given name to the FileSet.
+// Its lines are added with File.AddLine, which Lexers do as they go.
+func (s *FileSet) AddFile(filename string) *File {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	f := &File{name: filename, index: len(s.files) + 1, lines: []int{0}}
+	s.files = append(s.files, f)
+	return f
+}
+
+// Return the file containing p, or nil if there is none.
+func (s *FileSet) File(p Pos) *File {
+	index := int(p >> offsetBits)
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if index < 1 || index > len(s.files) {
+		return nil
+	}
+	return s.files[index-1]
+}
+
+// Turn p into a Position.
+// Returns the zero Position if p is not part of the FileSet.
+func (s *FileSet) Position(p Pos) Position {
+	if f := s.File(p); f != nil {
+		return f.Position(p)
+	}
+	return Position{}
+}
+
+// File is a single file within a FileSet.
+type File struct {
+	name    string
+	index   int
+	mutex   sync.Mutex
+	lines   []int
+	content string
+	known   bool
+	tr      *transcoding
+}
+
+// Return the name of the file.
+func (f *File) Name() string {
+	return f.name
+}
+
+// Return the Pos for the given byte offset in the file.
+func (f *File) Pos(offset int) Pos {
+	return Pos(f.index)<<offsetBits | Pos(offset)
+}
+
+// Return the byte offset of p in the file.
+func (f *File) Offset(p Pos) int {
+	return int(p & (1<<offsetBits - 1))
+}
+
+// Record that a line starts at the given offset.
+// Offsets not beyond the last recorded line are ignored.
+func (f *File) AddLine(offset int) {
+	f.mutex.Lock()
+	if offset > f.lines[len(f.lines)-1] {
+		f.lines = append(f.lines, offset)
+	}
+	f.mutex.Unlock()
+}
+
+// Record that the given line starts at the given offset.
+// The last recorded line may be moved forward, as happens with "\r\n".
+func (f *File) setLine(line, offset int) {
+	f.mutex.Lock()
+	last := len(f.lines) - 1
+	if line == last+2 && offset > f.lines[last] {
+		f.lines = append(f.lines, offset)
+	} else if line == last+1 && last > 0 && offset > f.lines[last] {
+		f.lines[last] = offset
+	}
+	f.mutex.Unlock()
+}
+
+// Return the number of lines recorded so far.
+func (f *File) LineCount() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.lines)
+}
+
+// Return the line number of p.
+func (f *File) Line(p Pos) int {
+	return f.Position(p).Line
+}
+
+// Turn p into a Position.
+// If the content of the file is known, Column counts runes, like the Column
+// of a Token. The content is not kept for input that is lexed in place or
+// read as it is lexed, as with NewBytes, NewFile, NewReader, and NewEncoded
+// for UTF-8; then Column counts bytes, so use the Column of the Token instead.
+func (f *File) Position(p Pos) Position {
+	offset := f.Offset(p)
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	line := sort.Search(len(f.lines), func(i int) bool {
+		return f.lines[i] > offset
+	})
+	start := f.lines[line-1]
+	col := offset - start + 1
+	if f.known {
+		from, to := start, offset
+		if f.tr != nil {
+			from, to = f.tr.decoded(from), f.tr.decoded(to)
+		}
+		if to <= len(f.content) {
+			col = utf8.RuneCountInString(f.content[from:to]) + 1
+		}
+	}
+	return Position{
+		Filename: f.name,
+		Offset:   offset,
+		Line:     line,
+		Column:   col,
+	}
+}
+
+// Make the content of the file known, so that columns can be counted in runes.
+// If the content was decoded, tr maps offsets in the file to the content.
+func (f *File) setContent(content string, tr *transcoding) {
+	f.mutex.Lock()
+	f.content = content
+	f.known = true
+	f.tr = tr
+	f.mutex.Unlock()
+}
@@ -0,0 +1,40 @@
+package lexer_test
+
+import (
+	"testing"
+
+	"github.com/PieterD/lexer"
+)
+
+func TestFileSet(t *testing.T) {
+	fset := lexer.NewFileSet()
+	state := func(l *lexer.LexInner) lexer.StateFn {
+		if !l.Find("*/") || !l.String("*/") {
+			return l.Errorf("no comment end")
+		}
+		l.Emit(1)
+		return l.EmitEof()
+	}
+	first := lexer.New("first", "/* ünï\ncode */", state).SetFileSet(fset).Iterate()
+	second := lexer.New("second", "\n\n/**/", state).SetFileSet(fset).Iterate()
+	a := first.Token()
+	b := second.Token()
+	if pos := fset.Position(a.Pos); pos.String() != "first:1:1" {
+		t.Fatalf("Unexpected start %s", pos)
+	}
+	if pos := fset.Position(a.End); pos.String() != "first:2:8" || pos.Offset != 16 {
+		t.Fatalf("Unexpected end %s (%d)", pos, pos.Offset)
+	}
+	if pos := fset.Position(b.End); pos.String() != "second:3:5" {
+		t.Fatalf("Unexpected start %s", pos)
+	}
+	if eof := first.Token(); eof.Pos != eof.End || fset.Position(eof.Pos).String() != "first:2:8" {
+		t.Fatalf("Unexpected EOF token %#v", eof)
+	}
+	if fset.File(b.Pos).Name() != "second" || fset.File(lexer.NoPos) != nil {
+		t.Fatalf("File lookup failed")
+	}
+	if pos := fset.Position(lexer.NoPos); pos.IsValid() || pos.String() != "-" {
+		t.Fatalf("Expected invalid position, got %s", pos)
+	}
+}
@@ -0,0 +1,195 @@
+package lexer
+
+import (
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// A StringProfile determines which string literals LexInner.QuotedString accepts,
+// and how their escape sequences are decoded.
+type StringProfile struct {
+	// Characters that open a string; it is closed by the same character.
+	Quotes string
+	// Characters that open a raw string, in which backslashes have no special meaning.
+	Raw string
+	// Characters that may follow a backslash to stand for a control character
+	// (abfnrtv) or for themselves (anything else, like \\ and \").
+	Escapes string
+	// Allow \xHH, which stands for a single byte.
+	Hex bool
+	// Allow \NNN with one to three octal digits, which stands for a single byte.
+	Octal bool
+	// The exact number of digits an octal escape must have, such as 3 in Go.
+	// If zero, it may have one to three.
+	OctalDigits int
+	// Allow \uHHHH and \UHHHHHHHH, which stand for a Unicode code point.
+	ShortUnicode, LongUnicode bool
+	// Combine \uHHHH escapes forming a UTF-16 surrogate pair, as in JSON.
+	Surrogates bool
+	// Allow a backslash at the end of a line, removing both.
+	Continuation bool
+	// Allow line endings in strings that are not raw. Raw strings always allow them.
+	// Which characters end a line is set with Lexer.SetLineEndings.
+	Newlines bool
+}
+
+var (
+	// Interpreted and raw string literals as in Go.
+	GoStrings = &StringProfile{
+		Quotes: `"`, Raw: "`", Escapes: `abfnrtv\"`,
+		Hex: true, Octal: true, OctalDigits: 3, ShortUnicode: true, LongUnicode: true,
+	}
+	// String and character literals as in C.
+	CStrings = &StringProfile{
+		Quotes: `"'`, Escapes: `abfnrtv\'"?`,
+		Hex: true, Octal: true, ShortUnicode: true, LongUnicode: true,
+		Continuation: true,
+	}
+	// Strings as in JSON.
+	JSONStrings = &StringProfile{
+		Quotes: `"`, Escapes: `bfnrt\"/`,
+		ShortUnicode: true, Surrogates: true,
+	}
+)
+
+// The control characters that simple escapes stand for.
+var controlEscapes = map[rune]string{
+	'a': "\a", 'b': "\b", 'f': "\f", 'n': "\n", 'r': "\r", 't': "\t", 'v': "\v",
+}
+
+// Accept a string literal as allowed by the profile.
+// The quotes are removed and escape sequences are decoded using Replace,
+// so that Emit sends the value of the string. Get still returns the literal
+// as it appears in the input.
+// If the input does not start with a quote, ScanNone is returned.
+// If the string is malformed, an error is emitted and ScanError is returned.
+// An invalid escape sequence is reported at its backslash; an unterminated
+// string at its opening quote.
+func (l *LexInner) QuotedString(p *StringProfile) Scan {
+	start := l.Mark()
+	open := l.Next()
+	raw := open != Eof && strings.ContainsRune(p.Raw, open)
+	if open == Eof || !raw && !strings.ContainsRune(p.Quotes, open) {
+		l.Unmark(start)
+		return ScanNone
+	}
+	l.Replace(start, "")
+	for {
+		mark := l.Mark()
+		char := l.Next()
+		switch {
+		case char == Eof:
+			l.Unmark(start)
+			return l.errorf("Unterminated string")
+		case char == open:
+			l.Replace(mark, "")
+			return ScanOK
+		case raw:
+		case l.endsLine(char) && !p.Newlines:
+			l.Unmark(mark)
+			return l.errorf("Newline in string")
+		case char == '\\':
+			if scan := l.escape(p, mark); scan != ScanOK {
+				return scan
+			}
+		}
+	}
+}
+
+// Decode the escape sequence following a backslash, which is at the given Mark.
+func (l *LexInner) escape(p *StringProfile, mark Mark) Scan {
+	char := l.Next()
+	var value string
+	switch {
+	case char == Eof:
+		return ScanOK
+	case l.endsLine(char) && p.Continuation:
+		if char == '\r' {
+			l.Accept("\n")
+		}
+	case strings.ContainsRune(p.Escapes, char):
+		value = string(char)
+		if control, ok := controlEscapes[char]; ok {
+			value = control
+		}
+	case char == 'x' && p.Hex:
+		b, ok := l.hexEscape(2)
+		if !ok {
+			l.Unmark(mark)
+			return l.errorf("Invalid hexadecimal escape sequence")
+		}
+		value = string([]byte{byte(b)})
+	case char >= '0' && char <= '7' && p.Octal:
+		b, n := char-'0', 1
+		for ; n < 3 && octalDigits.Contains(l.Peek()); n++ {
+			b = b*8 + l.Next() - '0'
+		}
+		if p.OctalDigits > 0 && n != p.OctalDigits {
+			l.Unmark(mark)
+			return l.errorf("Invalid octal escape sequence")
+		}
+		if b > 0xff {
+			l.Unmark(mark)
+			return l.errorf("Octal escape value %d is greater than 255", b)
+		}
+		value = string([]byte{byte(b)})
+	case char == 'u' && p.ShortUnicode, char == 'U' && p.LongUnicode:
+		n := 4
+		if char == 'U' {
+			n = 8
+		}
+		r, ok := l.hexEscape(n)
+		if !ok {
+			l.Unmark(mark)
+			return l.errorf("Invalid Unicode escape sequence")
+		}
+		if p.Surrogates && utf16.IsSurrogate(r) {
+			r = l.lowSurrogate(r)
+		}
+		if !utf8.ValidRune(r) {
+			l.Unmark(mark)
+			return l.errorf("Invalid Unicode code point in escape sequence")
+		}
+		value = string(r)
+	default:
+		l.Unmark(mark)
+		return l.errorf("Unknown escape sequence: \\%c", char)
+	}
+	l.Replace(mark, value)
+	return ScanOK
+}
+
+// Accept exactly n hexadecimal digits and return their value.
+func (l *LexInner) hexEscape(n int) (rune, bool) {
+	var r rune
+	for i := 0; i < n; i++ {
+		char := l.Peek()
+		switch {
+		case char >= '0' && char <= '9':
+			r = r*16 + char - '0'
+		case char >= 'a' && char <= 'f':
+			r = r*16 + char - 'a' + 10
+		case char >= 'A' && char <= 'F':
+			r = r*16 + char - 'A' + 10
+		default:
+			return 0, false
+		}
+		l.Next()
+	}
+	return r, true
+}
+
+// Combine a high surrogate with a following \uHHHH low surrogate.
+// If there is none, the escape is left alone and -1 is returned.
+func (l *LexInner) lowSurrogate(high rune) rune {
+	mark := l.Mark()
+	if l.String(`\u`) {
+		low, ok := l.hexEscape(4)
+		if r := utf16.DecodeRune(high, low); ok && r != utf8.RuneError {
+			return r
+		}
+	}
+	l.Unmark(mark)
+	return -1
+}
@@ -0,0 +1,124 @@
+package lexer_test
+
+import (
+	"testing"
+
+	"github.com/PieterD/lexer"
+)
+
+func quoteState(p *lexer.StringProfile) lexer.StateFn {
+	return func(l *lexer.LexInner) lexer.StateFn {
+		switch l.QuotedString(p) {
+		case lexer.ScanNone:
+			return l.Errorf("Not a string")
+		case lexer.ScanError:
+			return nil
+		}
+		l.Emit(TokenSymbol)
+		return nil
+	}
+}
+
+func TestQuotedString(t *testing.T) {
+	tests := []struct {
+		profile *lexer.StringProfile
+		input   string
+		val     string
+	}{
+		{lexer.GoStrings, `"hello"`, "hello"},
+		{lexer.GoStrings, `""+`, ""},
+		{lexer.GoStrings, `"a\tb\\c\"d"`, "a\tb\\c\"d"},
+		{lexer.GoStrings, `"\x41\101é\U0001F600"`, "AAé😀"},
+		{lexer.GoStrings, `"\xff"`, "\xff"},
+		{lexer.GoStrings, "`raw\\n\nstring`", "raw\\n\nstring"},
+		{lexer.CStrings, `'\''`, "'"},
+		{lexer.CStrings, `"\0"`, "\x00"},
+		{lexer.CStrings, `"\12x\1"`, "\nx\x01"},
+		{lexer.CStrings, "\"line\\\ncontinued\"", "linecontinued"},
+		{lexer.JSONStrings, `"😀\/"`, "😀/"},
+		{&lexer.StringProfile{Quotes: `"`, Newlines: true}, "\"a\nb\"", "a\nb"},
+	}
+	for _, test := range tests {
+		token := lexer.New("test", test.input, quoteState(test.profile)).Iterate().Token()
+		if token.Typ != TokenSymbol || token.Val != test.val {
+			t.Fatalf("%q: expected %q, got %#v", test.input, test.val, token)
+		}
+	}
+}
+
+func TestQuotedStringErrors(t *testing.T) {
+	tests := []struct {
+		profile *lexer.StringProfile
+		input   string
+		err     string
+		column  int
+	}{
+		{lexer.GoStrings, `'a'`, "Not a string", 1},
+		{lexer.GoStrings, `x = "abc`, "Unterminated string", 5},
+		{lexer.GoStrings, "\"abc\ndef\"", "Newline in string", 5},
+		{lexer.GoStrings, `"ab\qc"`, "Unknown escape sequence: \\q", 4},
+		{lexer.GoStrings, `"\x4"`, "Invalid hexadecimal escape sequence", 2},
+		{lexer.GoStrings, `"\777"`, "Octal escape value 511 is greater than 255", 2},
+		{lexer.GoStrings, `"\1"`, "Invalid octal escape sequence", 2},
+		{lexer.GoStrings, `"a\12x"`, "Invalid octal escape sequence", 3},
+		{lexer.GoStrings, `"é\u12"`, "Invalid Unicode escape sequence", 3},
+		{lexer.GoStrings, `"\ud800"`, "Invalid Unicode code point in escape sequence", 2},
+		{lexer.GoStrings, `"\U00110000"`, "Invalid Unicode code point in escape sequence", 2},
+		{lexer.JSONStrings, `"\ud83d\n"`, "Invalid Unicode code point in escape sequence", 2},
+		{lexer.JSONStrings, `"\x41"`, "Unknown escape sequence: \\x", 2},
+	}
+	for _, test := range tests {
+		ln := lexer.New("test", test.input, func(l *lexer.LexInner) lexer.StateFn {
+			l.Run(func(r rune) bool { return r != '"' && r != '\'' })
+			return quoteState(test.profile)
+		})
+		token := ln.Iterate().Token()
+		if token.Typ != lexer.TokenError || token.Val != test.err || token.Column != test.column {
+			t.Fatalf("%q: expected error %q at column %d, got %#v", test.input, test.err, test.column, token)
+		}
+	}
+}
+
+func TestQuotedStrings(t *testing.T) {
+	var state lexer.StateFn
+	state = func(l *lexer.LexInner) lexer.StateFn {
+		l.AcceptRun(" \n")
+		l.Ignore()
+		if l.Eof() {
+			return l.EmitEof()
+		}
+		if l.QuotedString(lexer.GoStrings) != lexer.ScanOK {
+			return nil
+		}
+		l.Emit(TokenString)
+		return state
+	}
+	tokens := []lexer.Token{
+		{Typ: TokenString, Val: "a\n", Line: 1, Column: 1},
+		{Typ: TokenString, Val: "b\nc", Line: 1, Column: 7},
+		{Typ: TokenString, Val: "é", Line: 3, Column: 1},
+		{Typ: lexer.TokenError, Val: "Unterminated string", Line: 3, Column: 10},
+	}
+	it := lexer.New("test", "\"a\\n\" `b\nc`\n\"\\u00e9\" \"x", state).Iterate()
+	for i, exp := range tokens {
+		token := it.Token()
+		if token.Typ != exp.Typ || token.Val != exp.Val || token.Line != exp.Line || token.Column != exp.Column {
+			t.Fatalf("Token %d: expected %#v, got %#v", i, exp, token)
+		}
+	}
+}
+
+func TestQuotedStringLineEndings(t *testing.T) {
+	token := lexer.New("test", "\"a\rb\"", quoteState(lexer.GoStrings)).Iterate().Token()
+	if token.Typ != TokenSymbol || token.Val != "a\rb" {
+		t.Fatalf("Expected a carriage return in the string, got %#v", token)
+	}
+	ln := lexer.New("test", "\"a\rb\"", quoteState(lexer.GoStrings)).SetLineEndings(lexer.AnyLineEnding)
+	if token := ln.Iterate().Token(); token.Typ != lexer.TokenError || token.Val != "Newline in string" || token.Column != 3 {
+		t.Fatalf("Expected an error for the line ending, got %#v", token)
+	}
+	ln = lexer.New("test", "\"a\\\r\nb\"", quoteState(lexer.CStrings)).SetLineEndings(lexer.AnyLineEnding)
+	if token := ln.Iterate().Token(); token.Typ != TokenSymbol || token.Val != "ab" {
+		t.Fatalf("Expected a continued string, got %#v", token)
+	}
+}
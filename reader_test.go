@@ -0,0 +1,101 @@
+package lexer_test
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/PieterD/lexer"
+)
+
+func TestReader(t *testing.T) {
+	var text = `
+foo = 500;
+barbaz="Hello world";
+`
+	expected := lexer.New("anonymous", text, symbolState).Iterate()
+	it := lexer.NewReader("anonymous", iotest.OneByteReader(strings.NewReader(text)), symbolState).Iterate()
+	for i := 0; ; i++ {
+		exp := expected.Token()
+		token := it.Token()
+		if token != exp {
+			t.Fatalf("Token %d invalid: %#v expected %#v", i, token, exp)
+		}
+		if token.Typ == lexer.TokenEmpty {
+			break
+		}
+	}
+}
+
+func TestReaderFind(t *testing.T) {
+	text := strings.Repeat("x", 10000) + "\n/* long\ncomment */"
+	state := func(l *lexer.LexInner) lexer.StateFn {
+		if !l.Find("/*") {
+			return l.Errorf("no comment")
+		}
+		l.Ignore()
+		if !l.Find("*/") || !l.String("*/") {
+			return l.Errorf("no comment end")
+		}
+		l.Emit(1)
+		return nil
+	}
+	it := lexer.NewReader("test", iotest.HalfReader(strings.NewReader(text)), state).Iterate()
+	token := it.Token()
+	if token.Val != "/* long\ncomment */" || token.Line != 2 || token.Column != 1 || token.Offset != 10001 {
+		t.Fatalf("Unexpected token %#v", token)
+	}
+}
+
+func TestReaderError(t *testing.T) {
+	r := iotest.TimeoutReader(iotest.OneByteReader(strings.NewReader("abc")))
+	state := func(l *lexer.LexInner) lexer.StateFn {
+		l.ExceptRun("")
+		l.Emit(1)
+		return nil
+	}
+	it := lexer.NewReader("test", r, state).Iterate()
+	if token := it.Token(); token.Typ != lexer.TokenError || token.Val != "Read error: "+iotest.ErrTimeout.Error() {
+		t.Fatalf("Expected read error, got %#v", token)
+	}
+}
+
+func TestReaderErrorStops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"foo = 5", []string{"foo", "="}},
+		{"foo = 500;\nbar", []string{"foo", "=", "500", ";"}},
+		{"foo = 5;", []string{"foo", "=", "5", ";"}},
+	}
+	for _, test := range tests {
+		for _, async := range []bool{false, true} {
+			ln := lexer.NewReader("test", iotest.TimeoutReader(strings.NewReader(test.input)), symbolState)
+			var tokens []lexer.Token
+			if async {
+				tokens = lexAll(ln)
+			} else {
+				it := ln.Iterate()
+				for len(tokens) < 10 {
+					token := it.Token()
+					tokens = append(tokens, token)
+					if token.Typ == lexer.TokenError || token.Typ == lexer.TokenEOF {
+						break
+					}
+				}
+				if token := it.Token(); token != (lexer.Token{}) {
+					t.Fatalf("%q: expected no more tokens, got %#v", test.input, token)
+				}
+			}
+			var got []string
+			for _, token := range tokens[:len(tokens)-1] {
+				got = append(got, token.Val)
+			}
+			last := tokens[len(tokens)-1]
+			if strings.Join(got, " ") != strings.Join(test.expected, " ") || last.Typ != lexer.TokenError || last.Val != "Read error: "+iotest.ErrTimeout.Error() {
+				t.Fatalf("%q: expected %q followed by a read error, got %v", test.input, test.expected, tokens)
+			}
+		}
+	}
+}
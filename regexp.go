@@ -0,0 +1,106 @@
+package lexer
+
+import (
+	"io"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+)
+
+// An AnchoredRegexp is a regular expression that only matches at the current
+// position, for use with LexInner.Regexp. It is safe for concurrent use, so
+// compile it once, for instance into a package-level variable, and share it
+// between Lexers.
+type AnchoredRegexp struct {
+	expr string
+	re   *regexp.Regexp
+}
+
+// Compile a regular expression that only matches at the current position,
+// so ^ is not needed. If longest is true, it prefers the longest match,
+// like Regexp.Longest; otherwise it prefers the first alternative that matches.
+func CompileAnchored(expr string, longest bool) (*AnchoredRegexp, error) {
+	// Check expr by itself first, so errors do not mention the added anchor.
+	if _, err := regexp.Compile(expr); err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(`^(?:` + expr + `)`)
+	if err != nil {
+		return nil, err
+	}
+	if longest {
+		re.Longest()
+	}
+	return &AnchoredRegexp{expr, re}, nil
+}
+
+// Like CompileAnchored, but panics if the expression cannot be parsed.
+func MustCompileAnchored(expr string, longest bool) *AnchoredRegexp {
+	re, err := CompileAnchored(expr, longest)
+	if err != nil {
+		panic(`lexer: CompileAnchored(` + strconv.Quote(expr) + `): ` + err.Error())
+	}
+	return re
+}
+
+// Return the source text of the regular expression, without the anchor.
+func (re *AnchoredRegexp) String() string {
+	return re.expr
+}
+
+// Reads runes from the current position onward, without consuming them.
+type runeReader struct {
+	l   *LexInner
+	off int
+}
+
+func (rr *runeReader) ReadRune() (rune, int, error) {
+	rr.l.fill(rr.off + utf8.UTFMax)
+	rest := rr.l.rest()[rr.off:]
+	if len(rest) == 0 {
+		return 0, 0, io.EOF
+	}
+	char, size := utf8.DecodeRuneInString(rest)
+	rr.off += size
+	return char, size, nil
+}
+
+// Return the submatch indices of re at the current position, or nil.
+func (l *LexInner) match(re *AnchoredRegexp) []int {
+	if l.reader != nil {
+		return re.re.FindReaderSubmatchIndex(&runeReader{l: l})
+	}
+	return re.re.FindStringSubmatchIndex(l.rest())
+}
+
+// Attempt to match the regular expression at the current position.
+// If it matches, the match is accepted and Regexp returns true.
+// Otherwise, nothing is accepted and it returns false.
+func (l *LexInner) Regexp(re *AnchoredRegexp) bool {
+	loc := l.match(re)
+	if loc == nil {
+		return false
+	}
+	l.accept(loc[1])
+	return true
+}
+
+// Like Regexp, but returns the match followed by the submatches of
+// each group, or nil if there is no match.
+// The submatches can be used with Replace, for instance to replace the
+// whole match with one of its groups.
+func (l *LexInner) RegexpSubmatch(re *AnchoredRegexp) []string {
+	loc := l.match(re)
+	if loc == nil {
+		return nil
+	}
+	rest := l.rest()
+	sub := make([]string, len(loc)/2)
+	for i := range sub {
+		if loc[2*i] >= 0 {
+			sub[i] = rest[loc[2*i]:loc[2*i+1]]
+		}
+	}
+	l.accept(loc[1])
+	return sub
+}
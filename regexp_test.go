@@ -0,0 +1,113 @@
+package lexer_test
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/PieterD/lexer"
+)
+
+var (
+	reFloat = lexer.MustCompileAnchored(`[0-9]+\.[0-9]+([eE][+-]?[0-9]+)?`, false)
+	reDate  = lexer.MustCompileAnchored(`(\d{4})-(\d{2})-(\d{2})`, false)
+	reLines = lexer.MustCompileAnchored(`(?s)<<.*?>>`, false)
+)
+
+const (
+	tokenFloat lexer.TokenType = 1 + iota
+	tokenDate
+	tokenBlock
+)
+
+func regexpState(l *lexer.LexInner) lexer.StateFn {
+	l.Run(func(r rune) bool { return r == ' ' || r == '\n' })
+	l.Ignore()
+	if l.Eof() {
+		return l.EmitEof()
+	}
+	start := l.Mark()
+	if sub := l.RegexpSubmatch(reDate); sub != nil {
+		l.Replace(start, sub[3]+"/"+sub[2]+"/"+sub[1])
+		l.Emit(tokenDate)
+		return regexpState
+	}
+	if l.Regexp(reFloat) {
+		l.Emit(tokenFloat)
+		return regexpState
+	}
+	if l.Regexp(reLines) {
+		l.Emit(tokenBlock)
+		return regexpState
+	}
+	return l.Errorf("Unexpected %q", l.Next())
+}
+
+func TestRegexp(t *testing.T) {
+	text := "2024-12-31 1.5e10 <<a\nb>> 3.14 x"
+	expected := []lexer.Token{
+		{Typ: tokenDate, Val: "31/12/2024", Line: 1, Column: 1},
+		{Typ: tokenFloat, Val: "1.5e10", Line: 1, Column: 12},
+		{Typ: tokenBlock, Val: "<<a\nb>>", Line: 1, Column: 19},
+		{Typ: tokenFloat, Val: "3.14", Line: 2, Column: 5},
+		{Typ: lexer.TokenError, Val: "Unexpected 'x'", Line: 2, Column: 11},
+	}
+	for _, ln := range []*lexer.Lexer{
+		lexer.New("test", text, regexpState),
+		lexer.NewReader("test", iotest.OneByteReader(strings.NewReader(text)), regexpState),
+	} {
+		tokens := lexAll(ln)
+		if len(tokens) != len(expected) {
+			t.Fatalf("Expected %d tokens, got %#v", len(expected), tokens)
+		}
+		for i, exp := range expected {
+			tok := tokens[i]
+			if tok.Typ != exp.Typ || tok.Val != exp.Val || tok.Line != exp.Line || tok.Column != exp.Column {
+				t.Fatalf("Token %d: expected %#v, got %#v", i, exp, tok)
+			}
+		}
+	}
+}
+
+func TestRegexpAnchored(t *testing.T) {
+	state := func(l *lexer.LexInner) lexer.StateFn {
+		if l.Regexp(reFloat) {
+			return l.Errorf("Matched %q, which is not at the current position", l.Get())
+		}
+		if l.Len() != 0 {
+			return l.Errorf("Consumed input without a match")
+		}
+		return l.EmitEof()
+	}
+	if token := lexer.New("test", "x 1.5", state).Iterate().Token(); token.Typ != lexer.TokenEOF {
+		t.Fatalf("Unexpected %#v", token)
+	}
+}
+
+func TestRegexpLongest(t *testing.T) {
+	for _, test := range []struct {
+		re  *lexer.AnchoredRegexp
+		val string
+	}{
+		{lexer.MustCompileAnchored(`a|ab`, false), "a"},
+		{lexer.MustCompileAnchored(`a|ab`, true), "ab"},
+	} {
+		state := func(l *lexer.LexInner) lexer.StateFn {
+			l.Regexp(test.re)
+			l.Emit(TokenSymbol)
+			return nil
+		}
+		if token := lexer.New("test", "abc", state).Iterate().Token(); token.Val != test.val {
+			t.Fatalf("%v: expected %q, got %#v", test.re, test.val, token)
+		}
+	}
+}
+
+func TestCompileAnchored(t *testing.T) {
+	if re := lexer.MustCompileAnchored(`a|b`, false); re.String() != "a|b" {
+		t.Fatalf("Expected the expression without the anchor, got %q", re.String())
+	}
+	if _, err := lexer.CompileAnchored(`a)(b`, false); err == nil || err.Error() != "error parsing regexp: unexpected ): `a)(b`" {
+		t.Fatalf("Unexpected error %v", err)
+	}
+}
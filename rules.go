@@ -0,0 +1,91 @@
+package lexer
+
+import "unicode/utf8"
+
+// A Rule matches a pattern at the current position, for use in Rules.
+// Its pattern is Literal if that is not empty, otherwise Regexp if that is not
+// nil, and otherwise a run of one or more characters in Set.
+type Rule struct {
+	Literal string
+	Regexp  *AnchoredRegexp
+	Set     CharSet
+	// The type of the token that is emitted for the match.
+	Type TokenType
+	// Ignore the match instead of emitting it, as for whitespace and comments.
+	Skip bool
+	// If set, called instead of emitting or ignoring the match, which has been
+	// accepted but not yet emitted. It returns the next state; next continues
+	// lexing with the Rules, so a hand-written state can return to it when done.
+	Action func(l *LexInner, next StateFn) StateFn
+}
+
+// Rules is a list of rules, which can be turned into a StateFn.
+type Rules []Rule
+
+// Return the length of the match at the current position, or 0.
+func (r *Rule) match(l *LexInner) int {
+	switch {
+	case r.Literal != "":
+		if l.HasPrefix(r.Literal) {
+			return len(r.Literal)
+		}
+	case r.Regexp != nil:
+		if loc := l.match(r.Regexp); loc != nil {
+			return loc[1]
+		}
+	default:
+		n := 0
+		for {
+			l.fill(n + utf8.UTFMax)
+			rest := l.rest()[n:]
+			if len(rest) == 0 {
+				return n
+			}
+			char, size := utf8.DecodeRuneInString(rest)
+			if !r.Set.Contains(char) {
+				return n
+			}
+			n += size
+		}
+	}
+	return 0
+}
+
+// Return a state that lexes using the rules, until the end of the input.
+// At every position, the rule with the longest match is used; if several
+// are equally long, the first of them is used. Matches of length zero are
+// not considered.
+// At the end of the input, EOF is emitted. If no rule matches, an error
+// is emitted.
+func (rs Rules) State() StateFn {
+	rs = append(Rules(nil), rs...)
+	var state StateFn
+	state = func(l *LexInner) StateFn {
+		for {
+			if l.Eof() {
+				return l.EmitEof()
+			}
+			best, length := -1, 0
+			for i := range rs {
+				if n := rs[i].match(l); n > length {
+					best, length = i, n
+				}
+			}
+			if best < 0 {
+				return l.Errorf("Unexpected character %q", l.Peek())
+			}
+			l.accept(length)
+			r := &rs[best]
+			switch {
+			case r.Action != nil:
+				return r.Action(l, state)
+			case r.Skip:
+				l.Ignore()
+			default:
+				l.Emit(r.Type)
+				return state
+			}
+		}
+	}
+	return state
+}
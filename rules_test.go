@@ -0,0 +1,63 @@
+package lexer_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/PieterD/lexer"
+)
+
+const (
+	tokenRuleIdent lexer.TokenType = 1 + iota
+	tokenRuleIf
+	tokenRuleNumber
+	tokenRuleAssign
+	tokenRuleEqual
+	tokenRuleString
+)
+
+var testRules = lexer.Rules{
+	{Set: lexer.Chars(" \t\n"), Skip: true},
+	{Regexp: lexer.MustCompileAnchored(`#.*`, false), Skip: true},
+	{Literal: "if", Type: tokenRuleIf},
+	{Set: lexer.Table(unicode.Letter), Type: tokenRuleIdent},
+	{Regexp: lexer.MustCompileAnchored(`[0-9]+(\.[0-9]+)?`, false), Type: tokenRuleNumber},
+	{Literal: "=", Type: tokenRuleAssign},
+	{Literal: "==", Type: tokenRuleEqual},
+	{Literal: `"`, Action: func(l *lexer.LexInner, next lexer.StateFn) lexer.StateFn {
+		l.Retry()
+		if l.QuotedString(lexer.GoStrings) != lexer.ScanOK {
+			return nil
+		}
+		l.Emit(tokenRuleString)
+		return next
+	}},
+}
+
+func lexRules(input string) string {
+	var got []string
+	for _, token := range lexAll(lexer.New("test", input, testRules.State())) {
+		got = append(got, fmt.Sprintf("%d:%s", token.Typ, token.Val))
+	}
+	return strings.Join(got, " ")
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"if iffy == 1.5 # comment\nx = \"a\\tb\" y", "2:if 1:iffy 5:== 3:1.5 1:x 4:= 6:a\tb 1:y -3:EOF"},
+		{"  ", "-3:EOF"},
+		{"a=b", "1:a 4:= 1:b -3:EOF"},
+		{"x $", `1:x -1:Unexpected character '$'`},
+		{`x "y`, "1:x -1:Unterminated string"},
+	}
+	for _, test := range tests {
+		if got := lexRules(test.input); got != test.expected {
+			t.Fatalf("%q: expected %s, got %s", test.input, test.expected, got)
+		}
+	}
+}
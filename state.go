@@ -0,0 +1,35 @@
+package lexer
+
+// The maximum number of states that may be pushed with PushState.
+// If this is exceeded, PushState reports an error.
+const MaxStateDepth = 64
+
+// Push a state to return to later using PopState, for instance before
+// lexing an interpolated expression within a string.
+// If MaxStateDepth would be exceeded, an error is emitted instead and
+// PushState returns false; the current state should then return nil.
+func (l *LexInner) PushState(state StateFn) bool {
+	if len(l.states) >= MaxStateDepth {
+		l.Errorf("State depth exceeds %d", MaxStateDepth)
+		return false
+	}
+	l.states = append(l.states, state)
+	return true
+}
+
+// Pop the state that was pushed last and return it, so that the current
+// state can resume it by returning it.
+// If no state was pushed, an error is emitted and PopState returns nil.
+func (l *LexInner) PopState() StateFn {
+	if len(l.states) == 0 {
+		return l.Errorf("No state to return to")
+	}
+	state := l.states[len(l.states)-1]
+	l.states = l.states[:len(l.states)-1]
+	return state
+}
+
+// Return the number of states that have been pushed and not yet popped.
+func (l *LexInner) StateDepth() int {
+	return len(l.states)
+}
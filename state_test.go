@@ -0,0 +1,90 @@
+package lexer_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/PieterD/lexer"
+)
+
+const (
+	tokenName lexer.TokenType = 1 + iota
+	tokenText
+	tokenOpen
+	tokenClose
+)
+
+// Lexes names and strings, which may contain ${...} with more names and strings.
+func codeState(l *lexer.LexInner) lexer.StateFn {
+	l.Whitespace("")
+	l.Ignore()
+	switch char := l.Next(); {
+	case char == lexer.Eof:
+		return l.EmitEof()
+	case char == '"':
+		l.Emit(tokenOpen)
+		if !l.PushState(codeState) {
+			return nil
+		}
+		return textState
+	case char == '}':
+		l.Emit(tokenClose)
+		return l.PopState()
+	default:
+		l.ExceptRun(" \"}")
+		l.Emit(tokenName)
+		return codeState
+	}
+}
+
+func textState(l *lexer.LexInner) lexer.StateFn {
+	for {
+		switch {
+		case l.String("${"):
+			l.Emit(tokenOpen)
+			if !l.PushState(textState) {
+				return nil
+			}
+			return codeState
+		case l.String(`"`):
+			l.Emit(tokenClose)
+			return l.PopState()
+		case l.Next() == lexer.Eof:
+			return l.EmitEof()
+		}
+		if l.HasPrefix("${") || l.HasPrefix(`"`) {
+			l.Emit(tokenText)
+		}
+	}
+}
+
+func TestStateStack(t *testing.T) {
+	ln := lexer.New("test", `a "b ${ c "d${e}" } f" g`, codeState)
+	var got []string
+	for _, token := range lexAll(ln) {
+		got = append(got, fmt.Sprintf("%d:%s", token.Typ, token.Val))
+	}
+	expected := `1:a 3:" 2:b  3:${ 1:c 3:" 2:d 3:${ 1:e 4:} 4:" 4:} 2: f 4:" 1:g -3:EOF`
+	if s := strings.Join(got, " "); s != expected {
+		t.Fatalf("Expected %s, got %s", expected, s)
+	}
+}
+
+func TestStateStackErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{`a "b ${ c`, "Unexpected end of input with 2 unpopped states"},
+		{`a }`, "No state to return to"},
+		{strings.Repeat(`"${`, 40), fmt.Sprintf("State depth exceeds %d", lexer.MaxStateDepth)},
+	}
+	for _, test := range tests {
+		tokens := lexAll(lexer.New("test", test.input, codeState))
+		last := tokens[len(tokens)-1]
+		if last.Typ != lexer.TokenError || last.Val != test.err {
+			t.Fatalf("%q: expected error %q, got %#v", test.input, test.err, last)
+		}
+	}
+}
@@ -3,13 +3,22 @@ package lexer
 import "fmt"
 
 // Tokens are emitted by the lexer. They contained a (usually) user-defined
-// Typ, the Value of the token, and the Filename and Line number where the
-// token was generated.
+// Typ, the Value of the token, and the Filename, Line, Column and byte Offset
+// where the token starts. VColumn is the visual column, with tabs expanded.
+// Line is the line of the first character of the token.
+// Pos and End span the token, and can be resolved using the FileSet of the Lexer.
+// Error, Warning and EOF tokens are positioned where the lexer was when
+// they were emitted, and have an empty span.
 type Token struct {
-	Typ  TokenType
-	Val  string
-	File string
-	Line int
+	Typ     TokenType
+	Val     string
+	File    string
+	Line    int
+	Column  int
+	VColumn int
+	Offset  int
+	Pos     Pos
+	End     Pos
 }
 
 // TokenType is an integer representing the type of token that has been emitted.